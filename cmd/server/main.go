@@ -2,23 +2,43 @@ package main
 
 import (
 	"context"
+	"os"
 
 	"github.com/joaopdias/blog-server/internal/api"
 	"github.com/joaopdias/blog-server/internal/config"
 	"github.com/joaopdias/blog-server/internal/database"
+	"github.com/joaopdias/blog-server/internal/shared/auth"
 )
 
 func main() {
-	
-	
 	config := config.Load()
-	pool, err := database.NewPool(context.Background(), config.DSN)
-	
+	ctx := context.Background()
+
+	pool, err := database.NewPool(ctx, config.DSN)
+
 	if err != nil {
 		panic("failed to connect with database: " + err.Error())
 	}
-	
-	api := api.NewRouter(pool)
 
-	api.Run(":"+config.Port)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, pool, os.Args[2:]); err != nil {
+			panic("failed to run migrations: " + err.Error())
+		}
+		return
+	}
+
+	if config.MigrateOnStart {
+		if err := migrateOnStart(ctx, pool); err != nil {
+			panic("failed to run migrations: " + err.Error())
+		}
+	}
+
+	keys, err := auth.LoadKeySet(config.JWTKeys, config.JWTSecret)
+	if err != nil {
+		panic("failed to load signing keys: " + err.Error())
+	}
+
+	api := api.NewRouter(ctx, pool, config, keys)
+
+	api.Run(":" + config.Port)
 }
@@ -0,0 +1,101 @@
+package activitypub
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ActivityPubController struct {
+	service *ActivityPubService
+}
+
+func NewActivityPubController(service *ActivityPubService) *ActivityPubController {
+	return &ActivityPubController{service: service}
+}
+
+func (c *ActivityPubController) RegisterRoutes(r *gin.Engine) {
+	r.GET("/.well-known/webfinger", c.WebFinger)
+	r.GET("/ap/users/:id", c.Actor)
+	r.GET("/ap/users/:id/outbox", c.Outbox)
+	r.GET("/ap/users/:id/followers", c.Followers)
+	r.POST("/ap/users/:id/inbox", c.Inbox)
+	r.GET("/ap/posts/:id", c.Article)
+}
+
+func (c *ActivityPubController) WebFinger(ctx *gin.Context) {
+	resource := ctx.Query("resource")
+	if resource == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing resource"})
+		return
+	}
+
+	finger, apiErr := c.service.WebFinger(ctx.Request.Context(), resource)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	render(ctx, "application/jrd+json", finger)
+}
+
+func (c *ActivityPubController) Actor(ctx *gin.Context) {
+	actor, apiErr := c.service.Actor(ctx.Request.Context(), ctx.Param("id"))
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	render(ctx, contentType, actor)
+}
+
+func (c *ActivityPubController) Outbox(ctx *gin.Context) {
+	outbox, apiErr := c.service.Outbox(ctx.Request.Context(), ctx.Param("id"))
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	render(ctx, contentType, outbox)
+}
+
+func (c *ActivityPubController) Followers(ctx *gin.Context) {
+	followers, apiErr := c.service.Followers(ctx.Request.Context(), ctx.Param("id"))
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	render(ctx, contentType, followers)
+}
+
+func (c *ActivityPubController) Article(ctx *gin.Context) {
+	article, apiErr := c.service.Article(ctx.Request.Context(), ctx.Param("id"))
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	render(ctx, contentType, article)
+}
+
+func (c *ActivityPubController) Inbox(ctx *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxBodySize))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if apiErr := c.service.Inbox(ctx.Request.Context(), ctx.Param("id"), ctx.Request, body); apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+func render(ctx *gin.Context, contentType string, value any) {
+	ctx.Header("Content-Type", contentType)
+	ctx.JSON(http.StatusOK, value)
+}
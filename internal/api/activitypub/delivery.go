@@ -0,0 +1,89 @@
+package activitypub
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	deliveryBatchSize   = 50
+	deliveryLease       = 5 * time.Minute
+	maxDeliveryAttempts = 10
+	maxDeliveryBackoff  = 24 * time.Hour
+)
+
+type DeliveryWorker struct {
+	service    *ActivityPubService
+	repository ActivityPubRepository
+	interval   time.Duration
+}
+
+func NewDeliveryWorker(service *ActivityPubService, repo ActivityPubRepository, interval time.Duration) *DeliveryWorker {
+	return &DeliveryWorker{service: service, repository: repo, interval: interval}
+}
+
+func (w *DeliveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *DeliveryWorker) tick(ctx context.Context) {
+	for {
+		deliveries, err := w.repository.ClaimDeliveries(ctx, deliveryBatchSize, deliveryLease)
+		if err != nil {
+			log.Printf("activitypub: failed to claim deliveries: %s", err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			w.deliver(ctx, delivery)
+		}
+
+		if len(deliveries) < deliveryBatchSize {
+			return
+		}
+	}
+}
+
+func (w *DeliveryWorker) deliver(ctx context.Context, delivery Delivery) {
+	deliverErr := w.service.Deliver(ctx, delivery)
+	if deliverErr == nil {
+		if err := w.repository.CompleteDelivery(ctx, delivery.Id); err != nil {
+			log.Printf("activitypub: failed to complete delivery %d: %s", delivery.Id, err)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= maxDeliveryAttempts {
+		log.Printf("activitypub: giving up on delivery %d to %s: %s", delivery.Id, delivery.Inbox, deliverErr)
+		if err := w.repository.CompleteDelivery(ctx, delivery.Id); err != nil {
+			log.Printf("activitypub: failed to drop delivery %d: %s", delivery.Id, err)
+		}
+		return
+	}
+
+	if err := w.repository.RetryDelivery(ctx, delivery.Id, attempts, time.Now().Add(backoff(attempts)), deliverErr.Error()); err != nil {
+		log.Printf("activitypub: failed to reschedule delivery %d: %s", delivery.Id, err)
+	}
+}
+
+// backoff doubles from one minute per failed attempt, capped at a day.
+func backoff(attempts int) time.Duration {
+	delay := time.Minute << (attempts - 1)
+	if delay <= 0 || delay > maxDeliveryBackoff {
+		return maxDeliveryBackoff
+	}
+	return delay
+}
@@ -0,0 +1,114 @@
+package activitypub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeliveryWorkerRetriesWithBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var signErr error
+	var publishedKey string
+
+	inbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+
+		body, _ := io.ReadAll(r.Body)
+		if params, err := parseSignature(r.Header.Get("Signature")); err != nil {
+			signErr = err
+		} else if key, err := parsePublicKey(publishedKey); err != nil {
+			signErr = err
+		} else if err := verify(r, body, params, key); err != nil {
+			signErr = err
+		}
+
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer inbox.Close()
+
+	service, repo := newTestService(t, inbox.Client())
+	ctx := context.Background()
+
+	actor, apiErr := service.Actor(ctx, "u1")
+	if apiErr != nil {
+		t.Fatal(apiErr.Message)
+	}
+	mu.Lock()
+	publishedKey = actor.PublicKey.PublicKeyPem
+	mu.Unlock()
+
+	repo.Enqueue(ctx, "u1", inbox.URL+"/inbox", []byte(`{"type":"Create"}`))
+	worker := NewDeliveryWorker(service, repo, time.Minute)
+
+	before := time.Now()
+	worker.tick(ctx)
+
+	if len(repo.retries) != 1 || len(repo.completed) != 0 {
+		t.Fatalf("after 500: retries %+v completed %+v", repo.retries, repo.completed)
+	}
+	if repo.retries[0].Attempts != 1 || !strings.Contains(repo.lastErrors[0], "500") {
+		t.Errorf("unexpected retry %+v %q", repo.retries[0], repo.lastErrors[0])
+	}
+	if delay := repo.retryAt[0].Sub(before); delay < time.Minute || delay > time.Minute+5*time.Second {
+		t.Errorf("first retry delay = %s, want one minute", delay)
+	}
+
+	repo.requeue(Delivery{Id: 1, UserId: "u1", Inbox: inbox.URL + "/inbox", Payload: []byte(`{"type":"Create"}`), Attempts: 1})
+	worker.tick(ctx)
+
+	if len(repo.completed) != 1 || repo.completed[0] != 1 || len(repo.retries) != 1 {
+		t.Fatalf("after 202: retries %+v completed %+v", repo.retries, repo.completed)
+	}
+	if calls != 2 {
+		t.Errorf("inbox called %d times, want 2", calls)
+	}
+	if signErr != nil {
+		t.Errorf("delivery signature did not verify: %s", signErr)
+	}
+}
+
+func TestDeliveryWorkerGivesUp(t *testing.T) {
+	inbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer inbox.Close()
+
+	service, repo := newTestService(t, inbox.Client())
+	worker := NewDeliveryWorker(service, repo, time.Minute)
+
+	repo.requeue(Delivery{Id: 7, UserId: "u1", Inbox: inbox.URL, Payload: []byte(`{}`), Attempts: maxDeliveryAttempts - 1})
+	worker.tick(context.Background())
+
+	if len(repo.retries) != 0 || len(repo.completed) != 1 {
+		t.Fatalf("retries %+v completed %+v", repo.retries, repo.completed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		5:  16 * time.Minute,
+		11: 1024 * time.Minute,
+		12: maxDeliveryBackoff,
+		80: maxDeliveryBackoff,
+	}
+	for attempts, want := range cases {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
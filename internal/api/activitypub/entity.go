@@ -0,0 +1,116 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	activityStreams = "https://www.w3.org/ns/activitystreams"
+	securityV1      = "https://w3id.org/security/v1"
+	public          = activityStreams + "#Public"
+	contentType     = "application/activity+json"
+)
+
+type Actor struct {
+	Context           []string   `json:"@context,omitempty"`
+	Id                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type PublicKey struct {
+	Id           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Article struct {
+	Context      []string `json:"@context,omitempty"`
+	Id           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	Name         string   `json:"name"`
+	Content      string   `json:"content"`
+	URL          string   `json:"url"`
+	Published    string   `json:"published"`
+	Updated      string   `json:"updated,omitempty"`
+	To           []string `json:"to"`
+	Cc           []string `json:"cc"`
+}
+
+type Tombstone struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type Activity struct {
+	Context []string `json:"@context,omitempty"`
+	Id      string   `json:"id"`
+	Type    string   `json:"type"`
+	Actor   string   `json:"actor"`
+	Object  any      `json:"object"`
+	To      []string `json:"to,omitempty"`
+	Cc      []string `json:"cc,omitempty"`
+}
+
+type Collection struct {
+	Context      []string `json:"@context,omitempty"`
+	Id           string   `json:"id"`
+	Type         string   `json:"type"`
+	TotalItems   int      `json:"totalItems"`
+	OrderedItems any      `json:"orderedItems,omitempty"`
+}
+
+type inboundActivity struct {
+	Id     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+type KeyPair struct {
+	PublicKeyPem  string
+	PrivateKeyPem string
+}
+
+type Follower struct {
+	UserId      string
+	ActorURI    string
+	Inbox       string
+	SharedInbox *string
+}
+
+type Delivery struct {
+	Id       int64
+	UserId   string
+	Inbox    string
+	Payload  []byte
+	Attempts int
+}
+
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type"`
+	Href string `json:"href"`
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,148 @@
+package activitypub
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ActivityPubRepository interface {
+	FindKey(ctx context.Context, userId string) (KeyPair, error)
+	CreateKey(ctx context.Context, userId string, key KeyPair) error
+	AddFollower(ctx context.Context, follower Follower) error
+	RemoveFollower(ctx context.Context, userId, actorURI string) error
+	CountFollowers(ctx context.Context, userId string) (int, error)
+	Enqueue(ctx context.Context, userId, inbox string, payload []byte) error
+	EnqueueForFollowers(ctx context.Context, userId string, payload []byte) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error)
+	CompleteDelivery(ctx context.Context, id int64) error
+	RetryDelivery(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error
+}
+
+type PostgresActivityPubRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresActivityPubRepository(pool *pgxpool.Pool) *PostgresActivityPubRepository {
+	return &PostgresActivityPubRepository{pool: pool}
+}
+
+func (r *PostgresActivityPubRepository) FindKey(ctx context.Context, userId string) (KeyPair, error) {
+	var key KeyPair
+	err := r.pool.QueryRow(ctx, `
+		SELECT public_key_pem, private_key_pem
+		FROM ap_actor_keys
+		WHERE user_id = $1
+	`, userId).Scan(&key.PublicKeyPem, &key.PrivateKeyPem)
+	return key, err
+}
+
+func (r *PostgresActivityPubRepository) CreateKey(ctx context.Context, userId string, key KeyPair) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ap_actor_keys (user_id, public_key_pem, private_key_pem)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+	`, userId, key.PublicKeyPem, key.PrivateKeyPem)
+	return err
+}
+
+func (r *PostgresActivityPubRepository) AddFollower(ctx context.Context, follower Follower) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ap_followers (user_id, actor_uri, inbox, shared_inbox)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, actor_uri) DO UPDATE
+		SET inbox = EXCLUDED.inbox, shared_inbox = EXCLUDED.shared_inbox
+	`, follower.UserId, follower.ActorURI, follower.Inbox, follower.SharedInbox)
+	return err
+}
+
+func (r *PostgresActivityPubRepository) RemoveFollower(ctx context.Context, userId, actorURI string) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM ap_followers
+		WHERE user_id = $1 AND actor_uri = $2
+	`, userId, actorURI)
+	return err
+}
+
+func (r *PostgresActivityPubRepository) CountFollowers(ctx context.Context, userId string) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		SELECT count(*)
+		FROM ap_followers
+		WHERE user_id = $1
+	`, userId).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresActivityPubRepository) Enqueue(ctx context.Context, userId, inbox string, payload []byte) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ap_deliveries (user_id, inbox, payload)
+		VALUES ($1, $2, $3)
+	`, userId, inbox, payload)
+	return err
+}
+
+// EnqueueForFollowers queues one delivery per distinct inbox, so followers on
+// the same server share a single request to its shared inbox.
+func (r *PostgresActivityPubRepository) EnqueueForFollowers(ctx context.Context, userId string, payload []byte) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO ap_deliveries (user_id, inbox, payload)
+		SELECT DISTINCT $1::uuid, COALESCE(shared_inbox, inbox), $2::jsonb
+		FROM ap_followers
+		WHERE user_id = $1
+	`, userId, payload)
+	return err
+}
+
+// ClaimDeliveries leases due deliveries by pushing their next attempt past
+// the lease, so a worker that dies mid-delivery only delays them and
+// replicas never send the same delivery concurrently.
+func (r *PostgresActivityPubRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE ap_deliveries d
+		SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		FROM (
+			SELECT id
+			FROM ap_deliveries
+			WHERE next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE d.id = due.id
+		RETURNING d.id, d.user_id, d.inbox, d.payload, d.attempts
+	`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var delivery Delivery
+		if err := rows.Scan(&delivery.Id, &delivery.UserId, &delivery.Inbox, &delivery.Payload, &delivery.Attempts); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *PostgresActivityPubRepository) CompleteDelivery(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM ap_deliveries
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *PostgresActivityPubRepository) RetryDelivery(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ap_deliveries
+		SET attempts = $2, next_attempt_at = $3, last_error = $4
+		WHERE id = $1
+	`, id, attempts, next, lastError)
+	return err
+}
@@ -0,0 +1,478 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/safehttp"
+)
+
+const (
+	outboxSize   = 20
+	maxBodySize  = 1 << 20
+	fetchTimeout = 10 * time.Second
+)
+
+// Client sends outgoing requests; tests and deployments behind a proxy can
+// swap in their own. The default one only connects to public addresses.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type ActivityPubService struct {
+	repository  ActivityPubRepository
+	postService *post.PostService
+	userService *user.UserService
+	client      Client
+	siteURL     string
+	host        string
+}
+
+func NewActivityPubService(repo ActivityPubRepository, postService *post.PostService, userService *user.UserService, client Client, siteURL string) *ActivityPubService {
+	if client == nil {
+		client = safehttp.NewClient(fetchTimeout)
+	}
+
+	siteURL = strings.TrimRight(siteURL, "/")
+	var host string
+	if u, err := url.Parse(siteURL); err == nil {
+		host = u.Host
+	}
+
+	return &ActivityPubService{
+		repository:  repo,
+		postService: postService,
+		userService: userService,
+		client:      client,
+		siteURL:     siteURL,
+		host:        host,
+	}
+}
+
+func (s *ActivityPubService) WebFinger(ctx context.Context, resource string) (WebFinger, *errors.ApiError) {
+	id, ok := s.resolveResource(resource)
+	if !ok {
+		return WebFinger{}, errors.NewApiError(http.StatusNotFound, "resource not found")
+	}
+
+	if _, apiErr := s.userService.FindById(ctx, id); apiErr != nil {
+		return WebFinger{}, errors.NewApiError(http.StatusNotFound, "resource not found")
+	}
+
+	return WebFinger{
+		Subject: "acct:" + id + "@" + s.host,
+		Aliases: []string{s.actorId(id)},
+		Links: []WebFingerLink{
+			{Rel: "self", Type: contentType, Href: s.actorId(id)},
+		},
+	}, nil
+}
+
+func (s *ActivityPubService) Actor(ctx context.Context, id string) (Actor, *errors.ApiError) {
+	author, apiErr := s.userService.FindById(ctx, id)
+	if apiErr != nil {
+		return Actor{}, errors.NewApiError(http.StatusNotFound, "actor not found")
+	}
+
+	key, apiErr := s.key(ctx, id)
+	if apiErr != nil {
+		return Actor{}, apiErr
+	}
+
+	actorId := s.actorId(id)
+	return Actor{
+		Context:           []string{activityStreams, securityV1},
+		Id:                actorId,
+		Type:              "Person",
+		PreferredUsername: id,
+		Name:              author.Name,
+		Inbox:             actorId + "/inbox",
+		Outbox:            actorId + "/outbox",
+		Followers:         actorId + "/followers",
+		PublicKey: PublicKey{
+			Id:           actorId + "#main-key",
+			Owner:        actorId,
+			PublicKeyPem: key.PublicKeyPem,
+		},
+	}, nil
+}
+
+func (s *ActivityPubService) Outbox(ctx context.Context, id string) (Collection, *errors.ApiError) {
+	if _, apiErr := s.userService.FindById(ctx, id); apiErr != nil {
+		return Collection{}, errors.NewApiError(http.StatusNotFound, "actor not found")
+	}
+
+	posts, _, apiErr := s.postService.FindAllByAuthor(ctx, id, true, outboxSize, nil)
+	if apiErr != nil {
+		return Collection{}, apiErr
+	}
+
+	activities := make([]Activity, 0, len(posts))
+	for _, p := range posts {
+		if !p.IsVisible() {
+			continue
+		}
+		activity := s.activity("Create", p, "")
+		activity.Context = nil
+		activities = append(activities, activity)
+	}
+
+	return Collection{
+		Context:      []string{activityStreams},
+		Id:           s.actorId(id) + "/outbox",
+		Type:         "OrderedCollection",
+		TotalItems:   len(activities),
+		OrderedItems: activities,
+	}, nil
+}
+
+func (s *ActivityPubService) Followers(ctx context.Context, id string) (Collection, *errors.ApiError) {
+	if _, apiErr := s.userService.FindById(ctx, id); apiErr != nil {
+		return Collection{}, errors.NewApiError(http.StatusNotFound, "actor not found")
+	}
+
+	count, err := s.repository.CountFollowers(ctx, id)
+	if err != nil {
+		return Collection{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return Collection{
+		Context:    []string{activityStreams},
+		Id:         s.actorId(id) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: count,
+	}, nil
+}
+
+func (s *ActivityPubService) Article(ctx context.Context, id string) (Article, *errors.ApiError) {
+	p, apiErr := s.postService.FindById(ctx, id)
+	if apiErr != nil {
+		return Article{}, apiErr
+	}
+	if !p.IsVisible() {
+		return Article{}, errors.NewApiError(http.StatusNotFound, "post not found")
+	}
+
+	article := s.article(p)
+	article.Context = []string{activityStreams}
+	return article, nil
+}
+
+// Inbox verifies the request's HTTP Signature against the sending actor's
+// published key before acting on Follow and Undo{Follow}; other activities
+// are accepted and ignored.
+func (s *ActivityPubService) Inbox(ctx context.Context, id string, req *http.Request, body []byte) *errors.ApiError {
+	if _, apiErr := s.userService.FindById(ctx, id); apiErr != nil {
+		return errors.NewApiError(http.StatusNotFound, "actor not found")
+	}
+
+	params, err := parseSignature(req.Header.Get("Signature"))
+	if err != nil {
+		return errors.NewApiError(http.StatusUnauthorized, err.Error())
+	}
+
+	remote, apiErr := s.fetchActor(ctx, strings.SplitN(params.KeyId, "#", 2)[0])
+	if apiErr != nil {
+		return apiErr
+	}
+	if remote.PublicKey.Id != params.KeyId || remote.PublicKey.Owner != remote.Id {
+		return errors.NewApiError(http.StatusUnauthorized, "unknown signing key")
+	}
+
+	key, err := parsePublicKey(remote.PublicKey.PublicKeyPem)
+	if err != nil {
+		return errors.NewApiError(http.StatusUnauthorized, "invalid signing key")
+	}
+	if err := verify(req, body, params, key); err != nil {
+		return errors.NewApiError(http.StatusUnauthorized, "invalid signature")
+	}
+
+	var activity inboundActivity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		return errors.NewApiError(http.StatusBadRequest, "invalid activity")
+	}
+	if activity.Actor != remote.Id {
+		return errors.NewApiError(http.StatusUnauthorized, "activity actor does not match signature")
+	}
+
+	switch activity.Type {
+	case "Follow":
+		if objectId(activity.Object) != s.actorId(id) {
+			return errors.NewApiError(http.StatusBadRequest, "follow target does not match inbox")
+		}
+		return s.acceptFollow(ctx, id, remote, body)
+	case "Undo":
+		var undone inboundActivity
+		if err := json.Unmarshal(activity.Object, &undone); err != nil || undone.Type != "Follow" {
+			return nil
+		}
+		if err := s.repository.RemoveFollower(ctx, id, remote.Id); err != nil {
+			return errors.NewApiError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *ActivityPubService) acceptFollow(ctx context.Context, id string, remote Actor, follow json.RawMessage) *errors.ApiError {
+	follower := Follower{UserId: id, ActorURI: remote.Id, Inbox: remote.Inbox}
+	if remote.Endpoints != nil && remote.Endpoints.SharedInbox != "" {
+		follower.SharedInbox = &remote.Endpoints.SharedInbox
+	}
+
+	if err := s.repository.AddFollower(ctx, follower); err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	payload, err := json.Marshal(Activity{
+		Context: []string{activityStreams},
+		Id:      s.actorId(id) + "#accepts/" + url.PathEscape(remote.Id),
+		Type:    "Accept",
+		Actor:   s.actorId(id),
+		Object:  follow,
+	})
+	if err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := s.repository.Enqueue(ctx, id, remote.Inbox, payload); err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func (s *ActivityPubService) PostPublished(ctx context.Context, published post.Post) {
+	s.broadcast(ctx, published, s.activity("Create", published, ""))
+}
+
+func (s *ActivityPubService) PostUpdated(ctx context.Context, updated post.Post) {
+	s.broadcast(ctx, updated, s.activity("Update", updated, fmt.Sprintf("#update-%d", updated.Version)))
+}
+
+func (s *ActivityPubService) PostDeleted(ctx context.Context, deleted post.Post) {
+	activity := s.activity("Delete", deleted, "#delete")
+	activity.Object = Tombstone{Id: s.objectId(deleted.ID), Type: "Tombstone"}
+	s.broadcast(ctx, deleted, activity)
+}
+
+func (s *ActivityPubService) broadcast(ctx context.Context, p post.Post, activity Activity) {
+	if !p.IsVisible() {
+		return
+	}
+
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		log.Printf("activitypub: failed to encode %s for post %s: %s", activity.Type, p.ID, err)
+		return
+	}
+
+	if err := s.repository.EnqueueForFollowers(ctx, p.AuthorId, payload); err != nil {
+		log.Printf("activitypub: failed to queue %s for post %s: %s", activity.Type, p.ID, err)
+	}
+}
+
+// Deliver posts one queued activity signed with its author's key. A
+// permanent refusal from the remote server is reported as delivered so the
+// worker does not keep retrying it.
+func (s *ActivityPubService) Deliver(ctx context.Context, delivery Delivery) error {
+	key, apiErr := s.key(ctx, delivery.UserId)
+	if apiErr != nil {
+		return stderrors.New(apiErr.Message)
+	}
+
+	private, err := parsePrivateKey(key.PrivateKeyPem)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.send(ctx, http.MethodPost, delivery.Inbox, delivery.Payload, s.actorId(delivery.UserId)+"#main-key", private)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("remote inbox responded %d", resp.StatusCode)
+	default:
+		log.Printf("activitypub: %s refused delivery %d with %d", delivery.Inbox, delivery.Id, resp.StatusCode)
+		return nil
+	}
+}
+
+func (s *ActivityPubService) fetchActor(ctx context.Context, uri string) (Actor, *errors.ApiError) {
+	resp, err := s.send(ctx, http.MethodGet, uri, nil, "", nil)
+	if err != nil {
+		return Actor{}, errors.NewApiError(http.StatusBadGateway, "failed to fetch remote actor")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Actor{}, errors.NewApiError(http.StatusUnauthorized, "remote actor not found")
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&actor); err != nil {
+		return Actor{}, errors.NewApiError(http.StatusBadGateway, "invalid remote actor")
+	}
+	if actor.Id != uri || !sameHost(actor.Inbox, actor.Id) {
+		return Actor{}, errors.NewApiError(http.StatusUnauthorized, "invalid remote actor")
+	}
+	if actor.Endpoints != nil && !sameHost(actor.Endpoints.SharedInbox, actor.Id) {
+		actor.Endpoints.SharedInbox = ""
+	}
+
+	return actor, nil
+}
+
+func (s *ActivityPubService) send(ctx context.Context, method, uri string, body []byte, keyId string, key *rsa.PrivateKey) (*http.Response, error) {
+	target, err := url.Parse(uri)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") {
+		return nil, fmt.Errorf("invalid url %q", uri)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if key != nil {
+		if err := sign(req, keyId, key, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.client.Do(req)
+}
+
+// key returns the author's signing key, generating it on first use. Racing
+// replicas may both generate one; only the first insert is kept.
+func (s *ActivityPubService) key(ctx context.Context, userId string) (KeyPair, *errors.ApiError) {
+	key, err := s.repository.FindKey(ctx, userId)
+	if err == nil {
+		return key, nil
+	}
+	if !stderrors.Is(err, pgx.ErrNoRows) {
+		return KeyPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	generated, err := generateKeyPair()
+	if err != nil {
+		return KeyPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+	if err := s.repository.CreateKey(ctx, userId, generated); err != nil {
+		return KeyPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	key, err = s.repository.FindKey(ctx, userId)
+	if err != nil {
+		return KeyPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+	return key, nil
+}
+
+func (s *ActivityPubService) activity(kind string, p post.Post, suffix string) Activity {
+	actorId := s.actorId(p.AuthorId)
+	return Activity{
+		Context: []string{activityStreams},
+		Id:      s.objectId(p.ID) + "/activity" + suffix,
+		Type:    kind,
+		Actor:   actorId,
+		Object:  s.article(p),
+		To:      []string{public},
+		Cc:      []string{actorId + "/followers"},
+	}
+}
+
+func (s *ActivityPubService) article(p post.Post) Article {
+	actorId := s.actorId(p.AuthorId)
+	published := p.CreatedAt
+	if p.PublishedAt != nil {
+		published = *p.PublishedAt
+	}
+
+	return Article{
+		Id:           s.objectId(p.ID),
+		Type:         "Article",
+		AttributedTo: actorId,
+		Name:         p.Title,
+		Content:      p.ContentHTML,
+		URL:          s.siteURL + "/posts/" + p.Slug,
+		Published:    formatTime(published),
+		Updated:      formatTime(p.UpdatedAt),
+		To:           []string{public},
+		Cc:           []string{actorId + "/followers"},
+	}
+}
+
+func (s *ActivityPubService) actorId(id string) string {
+	return s.siteURL + "/ap/users/" + id
+}
+
+func (s *ActivityPubService) objectId(id string) string {
+	return s.siteURL + "/ap/posts/" + id
+}
+
+func (s *ActivityPubService) resolveResource(resource string) (string, bool) {
+	if id, ok := strings.CutPrefix(resource, s.actorId("")); ok && id != "" && !strings.Contains(id, "/") {
+		return id, true
+	}
+
+	account, ok := strings.CutPrefix(resource, "acct:")
+	if !ok {
+		return "", false
+	}
+
+	id, host, ok := strings.Cut(account, "@")
+	if !ok || !strings.EqualFold(host, s.host) || id == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// sameHost reports whether uri is an http(s) URL on the same host as base,
+// so an actor can only have deliveries sent to its own server.
+func sameHost(uri, base string) bool {
+	target, err := url.Parse(uri)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") {
+		return false
+	}
+
+	origin, err := url.Parse(base)
+	return err == nil && target.Host != "" && strings.EqualFold(target.Host, origin.Host)
+}
+
+func objectId(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+
+	var object struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(raw, &object)
+	return object.Id
+}
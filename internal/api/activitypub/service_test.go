@@ -0,0 +1,460 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+)
+
+const siteURL = "https://blog.example"
+
+type fakeUsers struct {
+	user.UserRepository
+	users map[string]user.User
+}
+
+func (f fakeUsers) FindById(_ context.Context, id string) (user.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return user.User{}, pgx.ErrNoRows
+	}
+	return u, nil
+}
+
+type fanout struct {
+	userId  string
+	payload []byte
+}
+
+type fakeRepository struct {
+	mu         sync.Mutex
+	keys       map[string]KeyPair
+	followers  map[string]Follower
+	queued     []Delivery
+	fanouts    []fanout
+	completed  []int64
+	retries    []Delivery
+	retryAt    []time.Time
+	lastErrors []string
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{keys: map[string]KeyPair{}, followers: map[string]Follower{}}
+}
+
+func (f *fakeRepository) FindKey(_ context.Context, userId string) (KeyPair, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, ok := f.keys[userId]
+	if !ok {
+		return KeyPair{}, pgx.ErrNoRows
+	}
+	return key, nil
+}
+
+func (f *fakeRepository) CreateKey(_ context.Context, userId string, key KeyPair) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.keys[userId]; !ok {
+		f.keys[userId] = key
+	}
+	return nil
+}
+
+func (f *fakeRepository) AddFollower(_ context.Context, follower Follower) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.followers[follower.ActorURI] = follower
+	return nil
+}
+
+func (f *fakeRepository) RemoveFollower(_ context.Context, _, actorURI string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.followers, actorURI)
+	return nil
+}
+
+func (f *fakeRepository) CountFollowers(_ context.Context, _ string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.followers), nil
+}
+
+func (f *fakeRepository) Enqueue(_ context.Context, userId, inbox string, payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queued = append(f.queued, Delivery{Id: int64(len(f.queued) + 1), UserId: userId, Inbox: inbox, Payload: payload})
+	return nil
+}
+
+func (f *fakeRepository) EnqueueForFollowers(_ context.Context, userId string, payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fanouts = append(f.fanouts, fanout{userId: userId, payload: payload})
+	return nil
+}
+
+func (f *fakeRepository) ClaimDeliveries(_ context.Context, limit int, _ time.Duration) ([]Delivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	claimed := f.queued
+	if len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	f.queued = f.queued[len(claimed):]
+	return claimed, nil
+}
+
+func (f *fakeRepository) CompleteDelivery(_ context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.completed = append(f.completed, id)
+	return nil
+}
+
+func (f *fakeRepository) RetryDelivery(_ context.Context, id int64, attempts int, next time.Time, lastError string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retries = append(f.retries, Delivery{Id: id, Attempts: attempts})
+	f.retryAt = append(f.retryAt, next)
+	f.lastErrors = append(f.lastErrors, lastError)
+	return nil
+}
+
+func (f *fakeRepository) requeue(d Delivery) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queued = append(f.queued, d)
+}
+
+func newTestService(t *testing.T, client Client) (*ActivityPubService, *fakeRepository) {
+	t.Helper()
+
+	users := user.NewUserService(fakeUsers{users: map[string]user.User{
+		"u1": {Id: "u1", Name: "Ada"},
+	}}, nil)
+	posts := post.NewPostService(nil, users, nil, nil, "english", nil)
+	repo := newFakeRepository()
+
+	return NewActivityPubService(repo, posts, users, client, siteURL), repo
+}
+
+func newRouter(service *ActivityPubService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewActivityPubController(service).RegisterRoutes(r)
+	return r
+}
+
+// remoteActor is a fake fediverse server publishing one actor.
+type remoteActor struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	actor  Actor
+}
+
+func newRemoteActor(t *testing.T, mutate func(*Actor)) *remoteActor {
+	t.Helper()
+
+	pair, err := generateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := parsePrivateKey(pair.PrivateKeyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := &remoteActor{key: key}
+	remote.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/actor" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		json.NewEncoder(w).Encode(remote.actor)
+	}))
+	t.Cleanup(remote.server.Close)
+
+	id := remote.server.URL + "/actor"
+	remote.actor = Actor{
+		Id:    id,
+		Type:  "Person",
+		Inbox: remote.server.URL + "/inbox",
+		PublicKey: PublicKey{
+			Id:           id + "#main-key",
+			Owner:        id,
+			PublicKeyPem: pair.PublicKeyPem,
+		},
+	}
+	if mutate != nil {
+		mutate(&remote.actor)
+	}
+
+	return remote
+}
+
+func (r *remoteActor) signedRequest(t *testing.T, activity any) (*http.Request, []byte) {
+	t.Helper()
+
+	body, err := json.Marshal(activity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, siteURL+"/ap/users/u1/inbox", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if err := sign(req, r.actor.PublicKey.Id, r.key, body); err != nil {
+		t.Fatal(err)
+	}
+	return req, body
+}
+
+func follow(actor string) map[string]any {
+	return map[string]any{
+		"@context": activityStreams,
+		"id":       actor + "#follow/1",
+		"type":     "Follow",
+		"actor":    actor,
+		"object":   siteURL + "/ap/users/u1",
+	}
+}
+
+func TestWebFinger(t *testing.T) {
+	service, _ := newTestService(t, nil)
+	router := newRouter(service)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/webfinger?resource=acct:u1@blog.example", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var finger WebFinger
+	if err := json.Unmarshal(rec.Body.Bytes(), &finger); err != nil {
+		t.Fatal(err)
+	}
+	if finger.Subject != "acct:u1@blog.example" || len(finger.Links) != 1 || finger.Links[0].Href != siteURL+"/ap/users/u1" {
+		t.Fatalf("unexpected webfinger %+v", finger)
+	}
+
+	for _, resource := range []string{"acct:u1@elsewhere.example", "acct:missing@blog.example", siteURL + "/ap/users/u1/outbox"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/webfinger?resource="+resource, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", resource, rec.Code)
+		}
+	}
+}
+
+func TestActorDocument(t *testing.T) {
+	service, repo := newTestService(t, nil)
+	router := newRouter(service)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ap/users/u1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("content type = %q", got)
+	}
+
+	var actor Actor
+	if err := json.Unmarshal(rec.Body.Bytes(), &actor); err != nil {
+		t.Fatal(err)
+	}
+	actorId := siteURL + "/ap/users/u1"
+	if actor.Id != actorId || actor.Inbox != actorId+"/inbox" || actor.PublicKey.Id != actorId+"#main-key" || actor.Name != "Ada" {
+		t.Fatalf("unexpected actor %+v", actor)
+	}
+	if actor.PublicKey.PublicKeyPem != repo.keys["u1"].PublicKeyPem {
+		t.Error("actor does not publish the stored key")
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ap/users/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing actor status = %d", rec.Code)
+	}
+}
+
+func TestInboxFollowAndUndo(t *testing.T) {
+	remote := newRemoteActor(t, nil)
+	service, repo := newTestService(t, remote.server.Client())
+	router := newRouter(service)
+
+	req, _ := remote.signedRequest(t, follow(remote.actor.Id))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("follow status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	if follower, ok := repo.followers[remote.actor.Id]; !ok || follower.Inbox != remote.actor.Inbox {
+		t.Fatalf("follower not stored: %+v", repo.followers)
+	}
+	if len(repo.queued) != 1 || repo.queued[0].Inbox != remote.actor.Inbox {
+		t.Fatalf("accept not queued: %+v", repo.queued)
+	}
+	var accept inboundActivity
+	json.Unmarshal(repo.queued[0].Payload, &accept)
+	if accept.Type != "Accept" || accept.Actor != siteURL+"/ap/users/u1" {
+		t.Fatalf("unexpected accept %+v", accept)
+	}
+
+	req, _ = remote.signedRequest(t, map[string]any{
+		"id":     remote.actor.Id + "#undo/1",
+		"type":   "Undo",
+		"actor":  remote.actor.Id,
+		"object": follow(remote.actor.Id),
+	})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("undo status = %d, body %s", rec.Code, rec.Body)
+	}
+	if len(repo.followers) != 0 {
+		t.Fatalf("follower not removed: %+v", repo.followers)
+	}
+}
+
+func TestInboxRejectsTamperedSignature(t *testing.T) {
+	remote := newRemoteActor(t, nil)
+	service, repo := newTestService(t, remote.server.Client())
+	router := newRouter(service)
+
+	t.Run("body changed after signing", func(t *testing.T) {
+		req, body := remote.signedRequest(t, follow(remote.actor.Id))
+		tampered := bytes.Replace(body, []byte(`"Follow"`), []byte(`"Like"`), 1)
+		req.Body = io.NopCloser(bytes.NewReader(tampered))
+		req.ContentLength = int64(len(tampered))
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401", rec.Code)
+		}
+	})
+
+	t.Run("signature from another key", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := json.Marshal(follow(remote.actor.Id))
+		req := httptest.NewRequest(http.MethodPost, siteURL+"/ap/users/u1/inbox", bytes.NewReader(body))
+		if err := sign(req, remote.actor.PublicKey.Id, other, body); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401", rec.Code)
+		}
+	})
+
+	t.Run("signed header altered", func(t *testing.T) {
+		req, _ := remote.signedRequest(t, follow(remote.actor.Id))
+		req.Header.Set("Date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401", rec.Code)
+		}
+	})
+
+	if len(repo.followers) != 0 || len(repo.queued) != 0 {
+		t.Fatalf("tampered requests changed state: %+v %+v", repo.followers, repo.queued)
+	}
+}
+
+func TestInboxRejectsForeignInbox(t *testing.T) {
+	remote := newRemoteActor(t, func(a *Actor) {
+		a.Inbox = "http://169.254.169.254/latest/meta-data"
+	})
+	service, repo := newTestService(t, remote.server.Client())
+
+	req, body := remote.signedRequest(t, follow(remote.actor.Id))
+	if apiErr := service.Inbox(context.Background(), "u1", req, body); apiErr == nil || apiErr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", apiErr)
+	}
+	if len(repo.followers) != 0 || len(repo.queued) != 0 {
+		t.Fatal("actor with a foreign inbox was accepted")
+	}
+}
+
+func TestPostActivitiesFanOut(t *testing.T) {
+	service, repo := newTestService(t, nil)
+	ctx := context.Background()
+
+	visible := post.Post{
+		ID:               "p1",
+		Title:            "Hello",
+		Slug:             "hello",
+		Content:          "hi",
+		AuthorId:         "u1",
+		Status:           post.StatusPublished,
+		ModerationStatus: moderation.StatusApproved,
+		Version:          2,
+	}
+	draft := visible
+	draft.Status = post.StatusDraft
+
+	service.PostPublished(ctx, visible)
+	service.PostUpdated(ctx, visible)
+	service.PostDeleted(ctx, visible)
+	service.PostPublished(ctx, draft)
+
+	if len(repo.fanouts) != 3 {
+		t.Fatalf("fanouts = %d, want 3", len(repo.fanouts))
+	}
+
+	want := []struct{ kind, id string }{
+		{"Create", siteURL + "/ap/posts/p1/activity"},
+		{"Update", siteURL + "/ap/posts/p1/activity#update-2"},
+		{"Delete", siteURL + "/ap/posts/p1/activity#delete"},
+	}
+	for i, w := range want {
+		if repo.fanouts[i].userId != "u1" {
+			t.Errorf("%s queued for %q", w.kind, repo.fanouts[i].userId)
+		}
+
+		var activity struct {
+			Id     string         `json:"id"`
+			Type   string         `json:"type"`
+			Object map[string]any `json:"object"`
+		}
+		if err := json.Unmarshal(repo.fanouts[i].payload, &activity); err != nil {
+			t.Fatal(err)
+		}
+		if activity.Type != w.kind || activity.Id != w.id {
+			t.Errorf("activity %d = %s %s, want %s %s", i, activity.Type, activity.Id, w.kind, w.id)
+		}
+	}
+
+	var deleted struct {
+		Object Tombstone `json:"object"`
+	}
+	json.Unmarshal(repo.fanouts[2].payload, &deleted)
+	if deleted.Object.Type != "Tombstone" || deleted.Object.Id != siteURL+"/ap/posts/p1" {
+		t.Errorf("unexpected delete object %+v", deleted.Object)
+	}
+}
@@ -0,0 +1,226 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const signatureWindow = time.Hour
+
+type signatureParams struct {
+	KeyId     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+}
+
+// sign adds an HTTP Signature (draft-cavage-http-signatures-12, rsa-sha256)
+// covering the request target, host, date and, for requests with a body,
+// its digest, which is the set Mastodon and most fediverse servers expect.
+func sign(req *http.Request, keyId string, key *rsa.PrivateKey, body []byte) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", digest(body))
+		headers = append(headers, "digest")
+	}
+
+	signing, err := signingString(req, headers)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(signing))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyId, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+func verify(req *http.Request, body []byte, params signatureParams, key *rsa.PublicKey) error {
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, header := range required {
+		if !slices.Contains(params.Headers, header) {
+			return fmt.Errorf("signature does not cover %s", header)
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("invalid date header")
+	}
+	if skew := time.Since(date); skew > signatureWindow || skew < -signatureWindow {
+		return fmt.Errorf("date outside of signature window")
+	}
+
+	if len(body) > 0 && !digestMatches(req.Header.Get("Digest"), body) {
+		return fmt.Errorf("digest mismatch")
+	}
+
+	signing, err := signingString(req, params.Headers)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(signing))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], params.Signature)
+}
+
+func parseSignature(header string) (signatureParams, error) {
+	var params signatureParams
+
+	for _, part := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+
+		switch name {
+		case "keyId":
+			params.KeyId = value
+		case "algorithm":
+			params.Algorithm = value
+		case "headers":
+			params.Headers = strings.Fields(strings.ToLower(value))
+		case "signature":
+			signature, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return signatureParams{}, fmt.Errorf("invalid signature encoding")
+			}
+			params.Signature = signature
+		}
+	}
+
+	if params.KeyId == "" || len(params.Signature) == 0 {
+		return signatureParams{}, fmt.Errorf("missing signature")
+	}
+	if params.Algorithm != "" && params.Algorithm != "rsa-sha256" && params.Algorithm != "hs2019" {
+		return signatureParams{}, fmt.Errorf("unsupported signature algorithm")
+	}
+	if len(params.Headers) == 0 {
+		params.Headers = []string{"date"}
+	}
+
+	return params, nil
+}
+
+func signingString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		var value string
+		switch header {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		default:
+			values := req.Header.Values(header)
+			if len(values) == 0 {
+				return "", fmt.Errorf("missing signed header %s", header)
+			}
+			value = strings.Join(values, ", ")
+		}
+		lines[i] = header + ": " + value
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func digestMatches(header string, body []byte) bool {
+	expected := digest(body)
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if algorithm, _, ok := strings.Cut(value, "="); ok && strings.EqualFold(algorithm, "SHA-256") {
+			return "SHA-256="+value[len(algorithm)+1:] == expected
+		}
+	}
+	return false
+}
+
+func generateKeyPair() (KeyPair, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return KeyPair{}, err
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	return KeyPair{
+		PublicKeyPem:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})),
+		PrivateKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private})),
+	}, nil
+}
+
+func parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	private, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not RSA")
+	}
+	return private, nil
+}
+
+func parsePublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	public, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not RSA")
+	}
+	return public, nil
+}
@@ -0,0 +1,143 @@
+package comment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type CommentController struct {
+	service *CommentService
+}
+
+func NewCommentController(service *CommentService) *CommentController {
+	return &CommentController{service: service}
+}
+
+func (c *CommentController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.POST("/comment", guard.Required, c.Create)
+	r.GET("/comment/findMany", guard.Optional, c.FindMany)
+	r.PATCH("/comment", guard.Required, c.Update)
+	r.DELETE("/comment", guard.Required, c.Delete)
+}
+
+func (c *CommentController) Create(ctx *gin.Context) {
+	var dto CreateCommentDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	comment, err := c.service.Create(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "comment created",
+		"comment": comment,
+	})
+}
+
+func (c *CommentController) FindMany(ctx *gin.Context) {
+	postId := ctx.Query("postId")
+	if postId == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing postId"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var cursor *pagination.Cursor
+	if token := ctx.Query("cursor"); token != "" {
+		decoded, err := pagination.Decode(token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid cursor"})
+			return
+		}
+		cursor = &decoded
+	}
+
+	find := c.service.FindTree
+	switch ctx.DefaultQuery("mode", "tree") {
+	case "tree":
+	case "flat":
+		find = c.service.FindFlat
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid mode"})
+		return
+	}
+
+	comments, page, apiErr := find(ctx.Request.Context(), postId, limit, cursor)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	if link := pagination.LinkHeader(ctx.Request.URL, page); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "comments found",
+		"comments": comments,
+		"next":     page.Next,
+		"prev":     page.Prev,
+	})
+}
+
+func (c *CommentController) Update(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	var dto UpdateCommentDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	comment, err := c.service.Update(ctx.Request.Context(), id, dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "comment updated",
+		"comment": comment,
+	})
+}
+
+func (c *CommentController) Delete(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "comment deleted",
+	})
+}
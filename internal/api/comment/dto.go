@@ -0,0 +1,16 @@
+package comment
+
+import "github.com/joaopdias/blog-server/internal/api/moderation"
+
+type CreateCommentDTO struct {
+	PostId     string             `json:"postId" binding:"required"`
+	ParentId   *string            `json:"parentId"`
+	Content    string             `json:"content" binding:"required,max=10000"`
+	AuthorId   string             `json:"-"`
+	Moderation moderation.Verdict `json:"-"`
+}
+
+type UpdateCommentDTO struct {
+	Content    string              `json:"content" binding:"required,max=10000"`
+	Moderation *moderation.Verdict `json:"-"`
+}
@@ -0,0 +1,22 @@
+package comment
+
+import (
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+)
+
+type Comment struct {
+	Id               string            `json:"id"`
+	PostId           string            `json:"postId"`
+	ParentId         *string           `json:"parentId"`
+	AuthorId         string            `json:"authorId,omitempty"`
+	AuthorName       string            `json:"authorName,omitempty"`
+	Depth            int               `json:"depth"`
+	Content          string            `json:"content"`
+	Deleted          bool              `json:"deleted"`
+	ModerationStatus moderation.Status `json:"moderationStatus"`
+	EditedAt         *time.Time        `json:"editedAt"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	Replies          []Comment         `json:"replies,omitempty"`
+}
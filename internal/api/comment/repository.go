@@ -0,0 +1,278 @@
+package comment
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, createCommentDTO CreateCommentDTO) (Comment, error)
+	FindById(ctx context.Context, id string) (Comment, error)
+	FindFlat(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Comment, error)
+	FindRoots(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Comment, error)
+	FindThreads(ctx context.Context, rootIds []string) ([]Comment, error)
+	Update(ctx context.Context, id string, updateCommentDTO UpdateCommentDTO) (Comment, error)
+	SoftDelete(ctx context.Context, id string) error
+}
+
+const commentColumns = `c.id, c.post_id, c.parent_id, c.author_id, u.name, c.depth, c.content, c.deleted_at IS NOT NULL, c.moderation_status, c.edited_at, c.created_at`
+
+const commentSource = `
+		FROM comments c
+		JOIN users u ON u.id = c.author_id
+`
+
+type PostgresCommentRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresCommentRepository(pool *pgxpool.Pool) *PostgresCommentRepository {
+	return &PostgresCommentRepository{pool: pool}
+}
+
+func (r *PostgresCommentRepository) Create(ctx context.Context, createCommentDTO CreateCommentDTO) (Comment, error) {
+	query := `
+		WITH s AS (
+			SELECT gen_random_uuid() AS id, nextval(pg_get_serial_sequence('comments', 'seq')) AS seq
+		)
+		INSERT INTO comments (id, seq, post_id, parent_id, root_id, author_id, content, path, depth, moderation_status, moderation_reasons, spam_score)
+		SELECT
+			s.id,
+			s.seq,
+			$1,
+			parent.id,
+			COALESCE(parent.root_id, s.id),
+			$3,
+			$4,
+			COALESCE(parent.path || '.', '') || lpad(to_hex(s.seq), 12, '0'),
+			COALESCE(parent.depth + 1, 0),
+			$5,
+			$6,
+			$7
+		FROM s
+		LEFT JOIN comments parent ON parent.id = $2::uuid AND parent.post_id = $1
+		RETURNING id
+	`
+	var comment Comment
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var id string
+		err := tx.QueryRow(ctx, query,
+			createCommentDTO.PostId,
+			createCommentDTO.ParentId,
+			createCommentDTO.AuthorId,
+			createCommentDTO.Content,
+			createCommentDTO.Moderation.Status,
+			createCommentDTO.Moderation.Reasons,
+			createCommentDTO.Moderation.Score,
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		if createCommentDTO.Moderation.Status == moderation.StatusApproved {
+			_, err = tx.Exec(ctx, `
+				UPDATE posts
+				SET comment_count = comment_count + 1
+				WHERE id = $1
+			`, createCommentDTO.PostId)
+			if err != nil {
+				return err
+			}
+		}
+
+		comment, err = scanComment(tx.QueryRow(ctx, `SELECT `+commentColumns+commentSource+`WHERE c.id = $1`, id))
+		return err
+	})
+
+	if err != nil {
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
+
+func (r *PostgresCommentRepository) FindById(ctx context.Context, id string) (Comment, error) {
+	query := `
+		SELECT ` + commentColumns + commentSource + `
+		WHERE c.id = $1
+	`
+
+	return scanComment(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *PostgresCommentRepository) FindFlat(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Comment, error) {
+	page, args := pagination.Keyset(cursor, "c", 2, limit)
+	query := `
+		SELECT ` + commentColumns + commentSource + `
+		WHERE c.post_id = $1
+		  AND c.moderation_status = 'approved'
+		  AND c.deleted_at IS NULL` + page
+
+	return r.query(ctx, query, append([]any{postId}, args...)...)
+}
+
+func (r *PostgresCommentRepository) FindRoots(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Comment, error) {
+	page, args := pagination.Keyset(cursor, "c", 2, limit)
+	query := `
+		SELECT ` + commentColumns + commentSource + `
+		WHERE c.post_id = $1
+		  AND c.parent_id IS NULL
+		  AND c.moderation_status = 'approved'
+		  AND (c.deleted_at IS NULL OR EXISTS (
+			SELECT 1 FROM comments r
+			WHERE r.root_id = c.id AND r.deleted_at IS NULL AND r.moderation_status = 'approved'
+		  ))` + page
+
+	return r.query(ctx, query, append([]any{postId}, args...)...)
+}
+
+func (r *PostgresCommentRepository) FindThreads(ctx context.Context, rootIds []string) ([]Comment, error) {
+	query := `
+		SELECT ` + commentColumns + commentSource + `
+		WHERE c.root_id = ANY($1::uuid[])
+		  AND c.moderation_status = 'approved'
+		ORDER BY c.path
+	`
+
+	return r.query(ctx, query, rootIds)
+}
+
+func (r *PostgresCommentRepository) Update(ctx context.Context, id string, updateCommentDTO UpdateCommentDTO) (Comment, error) {
+	query := `
+		WITH updated AS (
+			UPDATE comments
+			SET content = $2,
+			    moderation_status = COALESCE($3, moderation_status),
+			    moderation_reasons = CASE WHEN $3::text IS NULL THEN moderation_reasons ELSE $4 END,
+			    spam_score = CASE WHEN $3::text IS NULL THEN spam_score ELSE $5 END,
+			    edited_at = now()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING *
+		)
+		SELECT ` + commentColumns + `
+		FROM updated c
+		JOIN users u ON u.id = c.author_id
+	`
+
+	var moderationStatus *moderation.Status
+	var moderationReasons []string
+	var spamScore float64
+	if verdict := updateCommentDTO.Moderation; verdict != nil {
+		moderationStatus, moderationReasons, spamScore = &verdict.Status, verdict.Reasons, verdict.Score
+	}
+
+	var comment Comment
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var previous moderation.Status
+		err := tx.QueryRow(ctx, `
+			SELECT moderation_status
+			FROM comments
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		`, id).Scan(&previous)
+		if err != nil {
+			return err
+		}
+
+		comment, err = scanComment(tx.QueryRow(ctx, query, id, updateCommentDTO.Content, moderationStatus, moderationReasons, spamScore))
+		if err != nil {
+			return err
+		}
+		if previous != moderation.StatusApproved || comment.ModerationStatus == moderation.StatusApproved {
+			return nil
+		}
+
+		// The held edit no longer counts until a moderator approves it again.
+		_, err = tx.Exec(ctx, `
+			UPDATE posts
+			SET comment_count = comment_count - 1
+			WHERE id = $1
+		`, comment.PostId)
+		return err
+	})
+
+	if err != nil {
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
+
+func (r *PostgresCommentRepository) SoftDelete(ctx context.Context, id string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var postId string
+		var status moderation.Status
+		err := tx.QueryRow(ctx, `
+			UPDATE comments
+			SET deleted_at = now()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING post_id, moderation_status
+		`, id).Scan(&postId, &status)
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if status != moderation.StatusApproved {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE posts
+			SET comment_count = comment_count - 1
+			WHERE id = $1
+		`, postId)
+		return err
+	})
+}
+
+func (r *PostgresCommentRepository) query(ctx context.Context, query string, args ...any) ([]Comment, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func scanComment(row pgx.Row) (Comment, error) {
+	var comment Comment
+
+	err := row.Scan(
+		&comment.Id,
+		&comment.PostId,
+		&comment.ParentId,
+		&comment.AuthorId,
+		&comment.AuthorName,
+		&comment.Depth,
+		&comment.Content,
+		&comment.Deleted,
+		&comment.ModerationStatus,
+		&comment.EditedAt,
+		&comment.CreatedAt,
+	)
+
+	if err != nil {
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
@@ -0,0 +1,231 @@
+package comment
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type CommentService struct {
+	repository  CommentRepository
+	postService *post.PostService
+	moderation  *moderation.ModerationService
+}
+
+func NewCommentService(repo CommentRepository, postService *post.PostService, moderationService *moderation.ModerationService) *CommentService {
+	return &CommentService{repository: repo, postService: postService, moderation: moderationService}
+}
+
+func (s *CommentService) Create(ctx context.Context, createCommentDTO CreateCommentDTO) (Comment, *errors.ApiError) {
+	actor, apiErr := user.Authorize(ctx, user.User.CanComment)
+	if apiErr != nil {
+		return Comment{}, apiErr
+	}
+
+	target, apiErr := s.postService.FindById(ctx, createCommentDTO.PostId)
+	if apiErr != nil {
+		return Comment{}, apiErr
+	}
+	if !target.IsVisible() {
+		return Comment{}, errors.NewApiError(http.StatusBadRequest, "post is not published")
+	}
+	if apiErr := checkLocked(target, actor); apiErr != nil {
+		return Comment{}, apiErr
+	}
+
+	if createCommentDTO.ParentId != nil {
+		parent, err := s.repository.FindById(ctx, *createCommentDTO.ParentId)
+		if err != nil || parent.PostId != target.ID || parent.ModerationStatus != moderation.StatusApproved {
+			return Comment{}, errors.NewApiError(http.StatusBadRequest, "parent comment does not exist")
+		}
+		if parent.Deleted {
+			return Comment{}, errors.NewApiError(http.StatusBadRequest, "cannot reply to a deleted comment")
+		}
+	}
+
+	createCommentDTO.AuthorId = actor.Id
+
+	verdict, apiErr := s.moderation.Screen(ctx, actor.Id, createCommentDTO.Content)
+	if apiErr != nil {
+		return Comment{}, apiErr
+	}
+	createCommentDTO.Moderation = verdict
+
+	comment, err := s.repository.Create(ctx, createCommentDTO)
+	if err != nil {
+		return Comment{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) FindFlat(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Comment, pagination.Page, *errors.ApiError) {
+	if _, apiErr := s.postService.FindById(ctx, postId); apiErr != nil {
+		return nil, pagination.Page{}, apiErr
+	}
+
+	comments, err := s.repository.FindFlat(ctx, postId, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	comments, page := pagination.Paginate(comments, limit, cursor, commentKey)
+	return comments, page, nil
+}
+
+func (s *CommentService) FindTree(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Comment, pagination.Page, *errors.ApiError) {
+	if _, apiErr := s.postService.FindById(ctx, postId); apiErr != nil {
+		return nil, pagination.Page{}, apiErr
+	}
+
+	roots, err := s.repository.FindRoots(ctx, postId, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	roots, page := pagination.Paginate(roots, limit, cursor, commentKey)
+	if len(roots) == 0 {
+		return roots, page, nil
+	}
+
+	rootIds := make([]string, len(roots))
+	for i, root := range roots {
+		rootIds[i] = root.Id
+	}
+
+	threads, err := s.repository.FindThreads(ctx, rootIds)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return buildTree(roots, threads), page, nil
+}
+
+func (s *CommentService) Update(ctx context.Context, id string, updateCommentDTO UpdateCommentDTO) (Comment, *errors.ApiError) {
+	current, apiErr := s.find(ctx, id)
+	if apiErr != nil {
+		return Comment{}, apiErr
+	}
+
+	actor, apiErr := user.Authorize(ctx, func(u user.User) bool { return u.CanEditComment(current.AuthorId) })
+	if apiErr != nil {
+		return Comment{}, apiErr
+	}
+
+	target, apiErr := s.postService.FindById(ctx, current.PostId)
+	if apiErr != nil {
+		return Comment{}, apiErr
+	}
+	if apiErr := checkLocked(target, actor); apiErr != nil {
+		return Comment{}, apiErr
+	}
+
+	if updateCommentDTO.Content != current.Content {
+		verdict, apiErr := s.moderation.Screen(ctx, current.AuthorId, updateCommentDTO.Content)
+		if apiErr != nil {
+			return Comment{}, apiErr
+		}
+		if verdict.Status != moderation.StatusApproved {
+			updateCommentDTO.Moderation = &verdict
+		}
+	}
+
+	comment, err := s.repository.Update(ctx, id, updateCommentDTO)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return Comment{}, errors.NewApiError(http.StatusNotFound, "comment not found")
+	}
+	if err != nil {
+		return Comment{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) Delete(ctx context.Context, id string) *errors.ApiError {
+	current, apiErr := s.find(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	target, apiErr := s.postService.FindById(ctx, current.PostId)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	_, apiErr = user.Authorize(ctx, func(u user.User) bool { return u.CanDeleteComment(current.AuthorId, target.AuthorId) })
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := s.repository.SoftDelete(ctx, id); err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func (s *CommentService) find(ctx context.Context, id string) (Comment, *errors.ApiError) {
+	comment, err := s.repository.FindById(ctx, id)
+	if err != nil || comment.Deleted {
+		return Comment{}, errors.NewApiError(http.StatusNotFound, "comment not found")
+	}
+
+	return comment, nil
+}
+
+func checkLocked(target post.Post, actor user.User) *errors.ApiError {
+	if target.CommentsLocked && !actor.CanManagePost(target.AuthorId) {
+		return errors.NewApiError(http.StatusForbidden, "comments are locked")
+	}
+	return nil
+}
+
+func commentKey(comment Comment) (time.Time, string) {
+	return comment.CreatedAt, comment.Id
+}
+
+func redact(comment Comment) Comment {
+	if comment.Deleted {
+		comment.Content = ""
+		comment.AuthorId = ""
+		comment.AuthorName = ""
+	}
+	return comment
+}
+
+func buildTree(roots, threads []Comment) []Comment {
+	children := map[string][]Comment{}
+	for _, comment := range threads {
+		if comment.ParentId != nil {
+			children[*comment.ParentId] = append(children[*comment.ParentId], comment)
+		}
+	}
+
+	var attach func(nodes []Comment) []Comment
+	attach = func(nodes []Comment) []Comment {
+		var kept []Comment
+		for _, node := range nodes {
+			node.Replies = attach(children[node.Id])
+			if node.Deleted && len(node.Replies) == 0 {
+				continue
+			}
+			kept = append(kept, redact(node))
+		}
+		return kept
+	}
+
+	tree := attach(roots)
+	if tree == nil {
+		tree = []Comment{}
+	}
+	return tree
+}
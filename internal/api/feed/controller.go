@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type FeedController struct {
+	service *FeedService
+}
+
+func NewFeedController(service *FeedService) *FeedController {
+	return &FeedController{service: service}
+}
+
+func (c *FeedController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.POST("/follow", guard.Required, c.Follow)
+	r.DELETE("/follow", guard.Required, c.Unfollow)
+	r.GET("/follow/followers", c.FindFollowers)
+	r.GET("/follow/following", c.FindFollowing)
+	r.GET("/feed", guard.Required, c.Feed)
+}
+
+func (c *FeedController) Follow(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	if err := c.service.Follow(ctx.Request.Context(), id); err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "user followed",
+	})
+}
+
+func (c *FeedController) Unfollow(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	if err := c.service.Unfollow(ctx.Request.Context(), id); err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "user unfollowed",
+	})
+}
+
+func (c *FeedController) FindFollowers(ctx *gin.Context) {
+	c.findFollows(ctx, c.service.FindFollowers)
+}
+
+func (c *FeedController) FindFollowing(ctx *gin.Context) {
+	c.findFollows(ctx, c.service.FindFollowing)
+}
+
+func (c *FeedController) findFollows(ctx *gin.Context, find func(context.Context, string, int, *pagination.Cursor) ([]Follow, pagination.Page, *errors.ApiError)) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	limit, cursor, ok := paging(ctx)
+	if !ok {
+		return
+	}
+
+	follows, page, apiErr := find(ctx.Request.Context(), id, limit, cursor)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	if link := pagination.LinkHeader(ctx.Request.URL, page); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "users found",
+		"users":   follows,
+		"next":    page.Next,
+		"prev":    page.Prev,
+	})
+}
+
+func (c *FeedController) Feed(ctx *gin.Context) {
+	limit, cursor, ok := paging(ctx)
+	if !ok {
+		return
+	}
+
+	posts, page, apiErr := c.service.Feed(ctx.Request.Context(), limit, cursor)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	if link := pagination.LinkHeader(ctx.Request.URL, page); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "posts found",
+		"posts":   posts,
+		"next":    page.Next,
+		"prev":    page.Prev,
+	})
+}
+
+func paging(ctx *gin.Context) (int, *pagination.Cursor, bool) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return 0, nil, false
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	token := ctx.Query("cursor")
+	if token == "" {
+		return limit, nil, true
+	}
+
+	cursor, err := pagination.Decode(token)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid cursor"})
+		return 0, nil, false
+	}
+
+	return limit, &cursor, true
+}
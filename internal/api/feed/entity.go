@@ -0,0 +1,9 @@
+package feed
+
+import "time"
+
+type Follow struct {
+	UserId    string    `json:"userId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,161 @@
+package feed
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type FeedRepository interface {
+	Follow(ctx context.Context, followerId, followeeId string, backfill int) error
+	Unfollow(ctx context.Context, followerId, followeeId string) error
+	FindFollowers(ctx context.Context, userId string, limit int, cursor *pagination.Cursor) ([]Follow, error)
+	FindFollowing(ctx context.Context, userId string, limit int, cursor *pagination.Cursor) ([]Follow, error)
+	FanOut(ctx context.Context, published post.Post, threshold int) error
+}
+
+type PostgresFeedRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresFeedRepository(pool *pgxpool.Pool) *PostgresFeedRepository {
+	return &PostgresFeedRepository{pool: pool}
+}
+
+func (r *PostgresFeedRepository) Follow(ctx context.Context, followerId, followeeId string, backfill int) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var created bool
+		err := tx.QueryRow(ctx, `
+			INSERT INTO follows (follower_id, followee_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+			RETURNING true
+		`, followerId, followeeId).Scan(&created)
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE users
+			SET follower_count = follower_count + 1
+			WHERE id = $1
+		`, followeeId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO feed_items (user_id, post_id, author_id, created_at)
+			SELECT $1, p.id, p.author_id, p.created_at
+			FROM posts p
+			WHERE p.author_id = $2 AND p.status = 'published' AND p.fanned_out
+			ORDER BY p.created_at DESC
+			LIMIT $3
+			ON CONFLICT DO NOTHING
+		`, followerId, followeeId, backfill)
+		return err
+	})
+}
+
+func (r *PostgresFeedRepository) Unfollow(ctx context.Context, followerId, followeeId string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM follows
+			WHERE follower_id = $1 AND followee_id = $2
+		`, followerId, followeeId)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE users
+			SET follower_count = follower_count - 1
+			WHERE id = $1
+		`, followeeId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			DELETE FROM feed_items
+			WHERE user_id = $1 AND author_id = $2
+		`, followerId, followeeId)
+		return err
+	})
+}
+
+func (r *PostgresFeedRepository) FindFollowers(ctx context.Context, userId string, limit int, cursor *pagination.Cursor) ([]Follow, error) {
+	return r.findFollows(ctx, "followee_id", "follower_id", userId, limit, cursor)
+}
+
+func (r *PostgresFeedRepository) FindFollowing(ctx context.Context, userId string, limit int, cursor *pagination.Cursor) ([]Follow, error) {
+	return r.findFollows(ctx, "follower_id", "followee_id", userId, limit, cursor)
+}
+
+func (r *PostgresFeedRepository) findFollows(ctx context.Context, match, other, userId string, limit int, cursor *pagination.Cursor) ([]Follow, error) {
+	page, args := pagination.Keyset(cursor, "f", 2, limit)
+	query := `
+		SELECT f.id, u.name, f.created_at
+		FROM (
+			SELECT ` + other + ` AS id, created_at
+			FROM follows
+			WHERE ` + match + ` = $1
+		) f
+		JOIN users u ON u.id = f.id
+		WHERE true` + page
+
+	rows, err := r.pool.Query(ctx, query, append([]any{userId}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []Follow{}
+	for rows.Next() {
+		var follow Follow
+		if err := rows.Scan(&follow.UserId, &follow.Name, &follow.CreatedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, follow)
+	}
+
+	return follows, rows.Err()
+}
+
+// FanOut writes a post to its author's followers' feeds when the author has at
+// most threshold followers, and marks it so feeds merge it at read time
+// otherwise.
+func (r *PostgresFeedRepository) FanOut(ctx context.Context, published post.Post, threshold int) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var id string
+		err := tx.QueryRow(ctx, `
+			UPDATE posts p
+			SET fanned_out = true
+			FROM users a
+			WHERE p.id = $1 AND a.id = p.author_id AND a.follower_count <= $2
+			RETURNING p.id
+		`, published.ID, threshold).Scan(&id)
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO feed_items (user_id, post_id, author_id, created_at)
+			SELECT f.follower_id, $1, $2, $3
+			FROM follows f
+			WHERE f.followee_id = $2
+			ON CONFLICT DO NOTHING
+		`, published.ID, published.AuthorId, published.CreatedAt)
+		return err
+	})
+}
@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+const followBackfill = 50
+
+type FeedService struct {
+	repository  FeedRepository
+	postService *post.PostService
+	userService *user.UserService
+	threshold   int
+}
+
+// NewFeedService fans published posts out to followers' feeds unless the
+// author has more than threshold followers; those authors' posts are merged
+// into feeds at read time instead.
+func NewFeedService(repo FeedRepository, postService *post.PostService, userService *user.UserService, threshold int) *FeedService {
+	return &FeedService{
+		repository:  repo,
+		postService: postService,
+		userService: userService,
+		threshold:   threshold,
+	}
+}
+
+func (s *FeedService) Follow(ctx context.Context, followeeId string) *errors.ApiError {
+	actor, apiErr := user.Authorize(ctx, user.User.CanFollow)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if actor.Id == followeeId {
+		return errors.NewApiError(http.StatusBadRequest, "cannot follow yourself")
+	}
+
+	if _, apiErr := s.userService.FindById(ctx, followeeId); apiErr != nil {
+		return errors.NewApiError(http.StatusNotFound, "user not found")
+	}
+
+	if err := s.repository.Follow(ctx, actor.Id, followeeId, followBackfill); err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func (s *FeedService) Unfollow(ctx context.Context, followeeId string) *errors.ApiError {
+	actor, apiErr := user.Authorize(ctx, user.User.CanFollow)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := s.repository.Unfollow(ctx, actor.Id, followeeId); err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func (s *FeedService) FindFollowers(ctx context.Context, userId string, limit int, cursor *pagination.Cursor) ([]Follow, pagination.Page, *errors.ApiError) {
+	follows, err := s.repository.FindFollowers(ctx, userId, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	follows, page := pagination.Paginate(follows, limit, cursor, followKey)
+	return follows, page, nil
+}
+
+func (s *FeedService) FindFollowing(ctx context.Context, userId string, limit int, cursor *pagination.Cursor) ([]Follow, pagination.Page, *errors.ApiError) {
+	follows, err := s.repository.FindFollowing(ctx, userId, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	follows, page := pagination.Paginate(follows, limit, cursor, followKey)
+	return follows, page, nil
+}
+
+func (s *FeedService) Feed(ctx context.Context, limit int, cursor *pagination.Cursor) ([]post.Post, pagination.Page, *errors.ApiError) {
+	actor, apiErr := user.Authorize(ctx, user.User.CanFollow)
+	if apiErr != nil {
+		return nil, pagination.Page{}, apiErr
+	}
+
+	return s.postService.FindManyByCursor(ctx, limit, cursor, post.PostFilter{FeedOf: actor.Id})
+}
+
+func (s *FeedService) PostPublished(ctx context.Context, published post.Post) {
+	if err := s.repository.FanOut(ctx, published, s.threshold); err != nil {
+		log.Printf("feed: failed to fan out post %s: %s", published.ID, err)
+	}
+}
+
+// Edits and withdrawals need no feed changes: feeds only reference posts and
+// visibility is checked when they are read.
+func (s *FeedService) PostUpdated(ctx context.Context, updated post.Post) {}
+
+func (s *FeedService) PostDeleted(ctx context.Context, deleted post.Post) {}
+
+func followKey(follow Follow) (time.Time, string) {
+	return follow.CreatedAt, follow.UserId
+}
@@ -0,0 +1,117 @@
+package media
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const MaxSize = 10 << 20
+
+var (
+	ErrUnsupportedType = stderrors.New("unsupported media type")
+	ErrTooLarge        = stderrors.New("media too large")
+)
+
+// contentTypes lists the extensions that can be uploaded. Uploads must also
+// sniff as the type their extension claims, so nothing served from the
+// media directory can be interpreted as HTML or script.
+var contentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+}
+
+type Store interface {
+	Save(name string, data []byte) (string, error)
+}
+
+// LocalStore keeps uploads on disk under dir, grouped by month, and serves
+// them from /media.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, siteURL string) *LocalStore {
+	return &LocalStore{dir: dir, baseURL: strings.TrimRight(siteURL, "/") + "/media"}
+}
+
+func (s *LocalStore) RegisterRoutes(r *gin.Engine) {
+	group := r.Group("/media", func(ctx *gin.Context) {
+		ctx.Header("X-Content-Type-Options", "nosniff")
+		ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	})
+	group.Static("/", s.dir)
+}
+
+func (s *LocalStore) Save(name string, data []byte) (string, error) {
+	if len(data) > MaxSize {
+		return "", ErrTooLarge
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	contentType, ok := contentTypes[ext]
+	if !ok || http.DetectContentType(data) != contentType {
+		return "", ErrUnsupportedType
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	month := time.Now().UTC().Format("2006/01")
+	file := sanitize(strings.TrimSuffix(path.Base(filepath.ToSlash(name)), filepath.Ext(name))) + "-" + hex.EncodeToString(suffix) + ext
+
+	dir := filepath.Join(s.dir, filepath.FromSlash(month))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, file), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	return s.baseURL + "/" + month + "/" + file, nil
+}
+
+func sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '_' || r == ' ' || r == '.':
+			b.WriteByte('-')
+		}
+	}
+
+	cleaned := strings.Trim(b.String(), "-")
+	if cleaned == "" {
+		return "upload"
+	}
+	if len(cleaned) > 64 {
+		cleaned = cleaned[:64]
+	}
+	return cleaned
+}
@@ -0,0 +1,285 @@
+package micropub
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/media"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+const maxFormSize = 10 << 20
+
+type MicropubController struct {
+	service *MicropubService
+}
+
+func NewMicropubController(service *MicropubService) *MicropubController {
+	return &MicropubController{service: service}
+}
+
+func (c *MicropubController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.GET("/micropub", accessToken, guard.Scoped, c.Query)
+	r.POST("/micropub", accessToken, guard.Scoped, c.Post)
+	r.POST("/micropub/media", accessToken, guard.Scoped, c.Media)
+}
+
+type request struct {
+	Type       []string        `json:"type"`
+	Properties Properties      `json:"properties"`
+	Action     string          `json:"action"`
+	URL        string          `json:"url"`
+	Replace    Properties      `json:"replace"`
+	Add        Properties      `json:"add"`
+	Delete     json.RawMessage `json:"delete"`
+}
+
+func (c *MicropubController) Query(ctx *gin.Context) {
+	switch ctx.Query("q") {
+	case "config":
+		ctx.JSON(http.StatusOK, c.service.Config())
+	case "syndicate-to":
+		ctx.JSON(http.StatusOK, gin.H{"syndicate-to": []string{}})
+	case "source":
+		// Source exposes drafts and raw content, which only clients allowed
+		// to edit posts need.
+		if !requireScope(ctx, "update") {
+			return
+		}
+
+		target := ctx.Query("url")
+		if target == "" {
+			fail(ctx, http.StatusBadRequest, "invalid_request", "missing url")
+			return
+		}
+
+		properties := ctx.QueryArray("properties[]")
+		if len(properties) == 0 {
+			properties = ctx.QueryArray("properties")
+		}
+
+		source, apiErr := c.service.Source(ctx.Request.Context(), target, properties)
+		if apiErr != nil {
+			failWith(ctx, apiErr)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, source)
+	default:
+		fail(ctx, http.StatusBadRequest, "invalid_request", "unsupported query")
+	}
+}
+
+func (c *MicropubController) Post(ctx *gin.Context) {
+	req, ok := parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	switch req.Action {
+	case "":
+		c.create(ctx, req)
+	case "update":
+		c.update(ctx, req)
+	case "delete":
+		if !requireScope(ctx, "delete") {
+			return
+		}
+		if apiErr := c.service.Delete(ctx.Request.Context(), req.URL); apiErr != nil {
+			failWith(ctx, apiErr)
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "post deleted"})
+	default:
+		fail(ctx, http.StatusBadRequest, "invalid_request", "unsupported action "+req.Action)
+	}
+}
+
+// Media accepts a single multipart file part, as the Micropub media endpoint
+// specifies, and answers with its URL in Location.
+func (c *MicropubController) Media(ctx *gin.Context) {
+	if !requireScope(ctx, "media") {
+		return
+	}
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		fail(ctx, http.StatusBadRequest, "invalid_request", "missing file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxSize+1))
+	if err != nil {
+		fail(ctx, http.StatusBadRequest, "invalid_request", "invalid file")
+		return
+	}
+
+	location, apiErr := c.service.Upload(ctx.Request.Context(), header.Filename, data)
+	if apiErr != nil {
+		failWith(ctx, apiErr)
+		return
+	}
+
+	ctx.Header("Location", location)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "media uploaded",
+		"url":     location,
+	})
+}
+
+func (c *MicropubController) create(ctx *gin.Context, req request) {
+	if len(req.Type) > 0 && req.Type[0] != "h-entry" {
+		fail(ctx, http.StatusBadRequest, "invalid_request", "only h-entry is supported")
+		return
+	}
+
+	claims, _ := user.TokenClaims(ctx)
+	draftOnly := !claims.HasScope("create") && claims.HasScope("draft")
+	if !draftOnly && !requireScope(ctx, "create") {
+		return
+	}
+
+	location, apiErr := c.service.Create(ctx.Request.Context(), req.Properties, draftOnly)
+	if apiErr != nil {
+		failWith(ctx, apiErr)
+		return
+	}
+
+	ctx.Header("Location", location)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "post created",
+		"url":     location,
+	})
+}
+
+func (c *MicropubController) update(ctx *gin.Context, req request) {
+	if !requireScope(ctx, "update") {
+		return
+	}
+
+	changes := Changes{Replace: req.Replace, Add: req.Add}
+	if len(req.Delete) > 0 {
+		if err := json.Unmarshal(req.Delete, &changes.DeleteNames); err != nil {
+			if err := json.Unmarshal(req.Delete, &changes.DeleteValues); err != nil {
+				fail(ctx, http.StatusBadRequest, "invalid_request", "invalid delete")
+				return
+			}
+		}
+	}
+
+	if apiErr := c.service.Update(ctx.Request.Context(), req.URL, changes); apiErr != nil {
+		failWith(ctx, apiErr)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "post updated"})
+}
+
+// parseRequest reads a JSON body, or a form-encoded or multipart one where
+// each field is a property and a trailing [] marks a list.
+func parseRequest(ctx *gin.Context) (request, bool) {
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+
+	if mediaType == "application/json" {
+		var req request
+		if err := json.NewDecoder(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxFormSize)).Decode(&req); err != nil {
+			fail(ctx, http.StatusBadRequest, "invalid_request", "invalid body")
+			return request{}, false
+		}
+		return req, true
+	}
+
+	if err := ctx.Request.ParseMultipartForm(maxFormSize); err != nil && err != http.ErrNotMultipart {
+		fail(ctx, http.StatusBadRequest, "invalid_request", "invalid body")
+		return request{}, false
+	}
+
+	form := ctx.Request.PostForm
+	req := request{
+		Action:     form.Get("action"),
+		URL:        form.Get("url"),
+		Properties: Properties{},
+	}
+	if h := form.Get("h"); h != "" {
+		req.Type = []string{"h-" + h}
+	}
+
+	for key, values := range form {
+		switch key {
+		case "access_token", "action", "h", "url":
+			continue
+		}
+
+		name := strings.TrimSuffix(key, "[]")
+		for _, value := range values {
+			req.Properties[name] = append(req.Properties[name], value)
+		}
+	}
+
+	if req.Action == "update" {
+		fail(ctx, http.StatusBadRequest, "invalid_request", "updates must be sent as JSON")
+		return request{}, false
+	}
+
+	return req, true
+}
+
+// accessToken lets clients send their token as an access_token parameter,
+// which the Micropub spec allows in place of the Authorization header.
+func accessToken(ctx *gin.Context) {
+	if ctx.GetHeader("Authorization") != "" {
+		ctx.Next()
+		return
+	}
+
+	token := ctx.Query("access_token")
+	if token == "" && ctx.Request.Method == http.MethodPost {
+		mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+		if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+			token = ctx.PostForm("access_token")
+		}
+	}
+
+	if token != "" {
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	ctx.Next()
+}
+
+func requireScope(ctx *gin.Context, scope string) bool {
+	claims, ok := user.TokenClaims(ctx)
+	if ok && claims.HasScope(scope) {
+		return true
+	}
+
+	fail(ctx, http.StatusForbidden, "insufficient_scope", "token lacks the "+scope+" scope")
+	return false
+}
+
+func failWith(ctx *gin.Context, apiErr *errors.ApiError) {
+	code := "invalid_request"
+	switch {
+	case apiErr.Code == http.StatusUnauthorized:
+		code = "unauthorized"
+	case apiErr.Code == http.StatusForbidden:
+		code = "forbidden"
+	case apiErr.Code >= http.StatusInternalServerError:
+		code = "server_error"
+	}
+
+	fail(ctx, apiErr.Code, code, apiErr.Message)
+}
+
+// fail replies with the error shape Micropub clients expect.
+func fail(ctx *gin.Context, status int, code, description string) {
+	ctx.AbortWithStatusJSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
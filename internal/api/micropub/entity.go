@@ -0,0 +1,55 @@
+package micropub
+
+import "fmt"
+
+// Properties are microformats2 properties: every value is a list, and
+// content may be a plain string or an object with html or value.
+type Properties map[string][]any
+
+func (p Properties) first(name string) string {
+	values := p.strings(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (p Properties) strings(name string) []string {
+	var values []string
+	for _, value := range p[name] {
+		switch v := value.(type) {
+		case string:
+			values = append(values, v)
+		case map[string]any:
+			for _, key := range []string{"html", "value"} {
+				if s, ok := v[key].(string); ok {
+					values = append(values, s)
+					break
+				}
+			}
+		case nil:
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	return values
+}
+
+type Changes struct {
+	Replace      Properties
+	Add          Properties
+	DeleteNames  []string
+	DeleteValues Properties
+}
+
+type PostType struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type Config struct {
+	MediaEndpoint string     `json:"media-endpoint"`
+	SyndicateTo   []string   `json:"syndicate-to"`
+	PostTypes     []PostType `json:"post-types"`
+	Q             []string   `json:"q"`
+}
@@ -0,0 +1,304 @@
+package micropub
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joaopdias/blog-server/internal/api/media"
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+const titleLength = 60
+
+type MicropubService struct {
+	postService *post.PostService
+	media       media.Store
+	siteURL     string
+	host        string
+}
+
+func NewMicropubService(postService *post.PostService, store media.Store, siteURL string) *MicropubService {
+	siteURL = strings.TrimRight(siteURL, "/")
+	var host string
+	if u, err := url.Parse(siteURL); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+
+	return &MicropubService{postService: postService, media: store, siteURL: siteURL, host: host}
+}
+
+func (s *MicropubService) Config() Config {
+	return Config{
+		MediaEndpoint: s.siteURL + "/micropub/media",
+		SyndicateTo:   []string{},
+		PostTypes: []PostType{
+			{Type: "note", Name: "Note"},
+			{Type: "article", Name: "Article"},
+		},
+		Q: []string{"config", "source", "syndicate-to"},
+	}
+}
+
+// Upload stores a file sent to the media endpoint and returns its URL.
+func (s *MicropubService) Upload(ctx context.Context, name string, data []byte) (string, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanWritePosts); apiErr != nil {
+		return "", apiErr
+	}
+
+	url, err := s.media.Save(name, data)
+	switch {
+	case stderrors.Is(err, media.ErrUnsupportedType):
+		return "", errors.NewApiError(http.StatusUnsupportedMediaType, err.Error())
+	case stderrors.Is(err, media.ErrTooLarge):
+		return "", errors.NewApiError(http.StatusRequestEntityTooLarge, err.Error())
+	case err != nil:
+		return "", errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return url, nil
+}
+
+// Create maps an h-entry onto a post: name becomes the title (derived from
+// the content for notes), category the tags and mp-slug the slug. Tokens
+// limited to the draft scope can only create drafts.
+func (s *MicropubService) Create(ctx context.Context, properties Properties, draftOnly bool) (string, *errors.ApiError) {
+	actor, ok := user.FromContext(ctx)
+	if !ok {
+		return "", errors.NewApiError(http.StatusUnauthorized, "unauthenticated")
+	}
+
+	content, name := properties.first("content"), strings.TrimSpace(properties.first("name"))
+	if strings.TrimSpace(content) == "" {
+		return "", errors.NewApiError(http.StatusBadRequest, "missing content")
+	}
+	if name == "" {
+		name = titleFrom(content)
+	}
+
+	dto := post.CreatePostDTO{
+		Title:    name,
+		Slug:     properties.first("mp-slug"),
+		Content:  content,
+		Tags:     properties.strings("category"),
+		AuthorId: actor.Id,
+	}
+
+	status, apiErr := postStatus(properties.first("post-status"))
+	if apiErr != nil {
+		return "", apiErr
+	}
+	dto.Status = status
+
+	if published := properties.first("published"); published != "" {
+		at, err := time.Parse(time.RFC3339, published)
+		if err != nil {
+			return "", errors.NewApiError(http.StatusBadRequest, "invalid published date")
+		}
+		dto.PublishedAt = &at
+		if dto.Status == post.StatusPublished && at.After(time.Now()) {
+			dto.Status = post.StatusScheduled
+		}
+	}
+
+	if draftOnly {
+		dto.Status, dto.PublishedAt = post.StatusDraft, nil
+	}
+
+	created, apiErr := s.postService.Create(ctx, dto)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	return s.postURL(created), nil
+}
+
+func (s *MicropubService) Update(ctx context.Context, target string, changes Changes) *errors.ApiError {
+	current, apiErr := s.find(ctx, target)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var dto post.UpdatePostDTO
+	tags := tagNames(current)
+	tagsChanged := false
+
+	for name := range changes.Replace {
+		values := changes.Replace.strings(name)
+		switch name {
+		case "name", "content":
+			if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+				return errors.NewApiError(http.StatusBadRequest, name+" cannot be empty")
+			}
+			if name == "name" {
+				dto.Title = &values[0]
+			} else {
+				dto.Content = &values[0]
+			}
+		case "category":
+			tags, tagsChanged = values, true
+		case "mp-slug":
+			if len(values) > 0 {
+				dto.Slug = &values[0]
+			}
+		case "post-status":
+			status, apiErr := postStatus(changes.Replace.first(name))
+			if apiErr != nil {
+				return apiErr
+			}
+			dto.Status = &status
+		default:
+			return errors.NewApiError(http.StatusBadRequest, "unsupported property "+name)
+		}
+	}
+
+	for name := range changes.Add {
+		if name != "category" {
+			return errors.NewApiError(http.StatusBadRequest, "cannot add to property "+name)
+		}
+		for _, tag := range changes.Add.strings(name) {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		tagsChanged = true
+	}
+
+	for _, name := range changes.DeleteNames {
+		if name != "category" {
+			return errors.NewApiError(http.StatusBadRequest, "cannot delete property "+name)
+		}
+		tags, tagsChanged = []string{}, true
+	}
+
+	for name := range changes.DeleteValues {
+		if name != "category" {
+			return errors.NewApiError(http.StatusBadRequest, "cannot delete from property "+name)
+		}
+		removed := changes.DeleteValues.strings(name)
+		tags = slices.DeleteFunc(tags, func(tag string) bool { return slices.Contains(removed, tag) })
+		tagsChanged = true
+	}
+
+	if tagsChanged {
+		dto.Tags = &tags
+	}
+
+	// Micropub has no notion of versions, so the edit applies to whatever
+	// version was just read.
+	_, apiErr = s.postService.Update(ctx, current.ID, current.Version, dto)
+	return apiErr
+}
+
+func (s *MicropubService) Delete(ctx context.Context, target string) *errors.ApiError {
+	current, apiErr := s.find(ctx, target)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return s.postService.Delete(ctx, current.ID)
+}
+
+// Source returns a post as an h-entry, limited to the requested properties
+// when any are given.
+func (s *MicropubService) Source(ctx context.Context, target string, properties []string) (map[string]any, *errors.ApiError) {
+	current, apiErr := s.find(ctx, target)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	status := "published"
+	if !current.IsPublished() {
+		status = "draft"
+	}
+
+	all := map[string][]any{
+		"name":        {current.Title},
+		"content":     {current.Content},
+		"category":    toAny(tagNames(current)),
+		"mp-slug":     {current.Slug},
+		"post-status": {status},
+		"url":         {s.postURL(current)},
+	}
+	if current.PublishedAt != nil {
+		all["published"] = []any{current.PublishedAt.Format(time.RFC3339)}
+	}
+
+	if len(properties) == 0 {
+		return map[string]any{"type": []string{"h-entry"}, "properties": all}, nil
+	}
+
+	selected := map[string][]any{}
+	for _, name := range properties {
+		if values, ok := all[name]; ok {
+			selected[name] = values
+		}
+	}
+	return map[string]any{"properties": selected}, nil
+}
+
+func (s *MicropubService) find(ctx context.Context, target string) (post.Post, *errors.ApiError) {
+	u, err := url.Parse(target)
+	if err != nil || !strings.EqualFold(u.Host, s.host) {
+		return post.Post{}, errors.NewApiError(http.StatusBadRequest, "url does not belong to this site")
+	}
+
+	slug, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/posts/")
+	if !ok || slug == "" {
+		return post.Post{}, errors.NewApiError(http.StatusBadRequest, "url is not a post")
+	}
+
+	return s.postService.FindBySlug(ctx, slug)
+}
+
+func (s *MicropubService) postURL(p post.Post) string {
+	return s.siteURL + "/posts/" + p.Slug
+}
+
+func postStatus(value string) (post.Status, *errors.ApiError) {
+	switch value {
+	case "", "published":
+		return post.StatusPublished, nil
+	case "draft":
+		return post.StatusDraft, nil
+	}
+	return "", errors.NewApiError(http.StatusBadRequest, "invalid post-status")
+}
+
+// titleFrom uses the first line of a note, cut at a word boundary.
+func titleFrom(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	line = strings.Join(strings.Fields(line), " ")
+	if utf8.RuneCountInString(line) <= titleLength {
+		return line
+	}
+
+	runes := []rune(line)[:titleLength]
+	if i := strings.LastIndexByte(string(runes), ' '); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+func tagNames(p post.Post) []string {
+	names := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func toAny(values []string) []any {
+	converted := make([]any, len(values))
+	for i, value := range values {
+		converted[i] = value
+	}
+	return converted
+}
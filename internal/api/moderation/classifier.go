@@ -0,0 +1,80 @@
+package moderation
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const maxTokens = 500
+
+type Corpus struct {
+	SpamDocs int
+	HamDocs  int
+}
+
+type TokenCount struct {
+	Spam int
+	Ham  int
+}
+
+var urlPattern = regexp.MustCompile(`(?i)https?://[^\s<>"')]+`)
+
+// tokenize returns the distinct words of text plus one "host:" token per
+// linked domain, which is what the classifier counts per document.
+func tokenize(text string) []string {
+	seen := map[string]bool{}
+	var tokens []string
+
+	add := func(token string) {
+		if len(tokens) < maxTokens && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, link := range urlPattern.FindAllString(text, -1) {
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			add("host:" + strings.ToLower(u.Hostname()))
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	for _, word := range words {
+		word = strings.Trim(word, "'")
+		if n := len([]rune(word)); n >= 2 && n <= 40 {
+			add(word)
+		}
+	}
+
+	return tokens
+}
+
+// spamProbability scores a document with a naive-Bayes model over document
+// frequencies, using Laplace smoothing. Tokens never seen in training carry
+// no evidence and are skipped. Without examples of both classes there is
+// nothing to compare against, so the score is 0.
+func spamProbability(corpus Corpus, counts map[string]TokenCount, tokens []string) float64 {
+	if corpus.SpamDocs == 0 || corpus.HamDocs == 0 {
+		return 0
+	}
+
+	total := float64(corpus.SpamDocs + corpus.HamDocs)
+	logSpam := math.Log(float64(corpus.SpamDocs) / total)
+	logHam := math.Log(float64(corpus.HamDocs) / total)
+
+	for _, token := range tokens {
+		count, ok := counts[token]
+		if !ok {
+			continue
+		}
+		logSpam += math.Log((float64(count.Spam) + 1) / (float64(corpus.SpamDocs) + 2))
+		logHam += math.Log((float64(count.Ham) + 1) / (float64(corpus.HamDocs) + 2))
+	}
+
+	return 1 / (1 + math.Exp(logHam-logSpam))
+}
@@ -0,0 +1,92 @@
+package moderation
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type ModerationController struct {
+	service *ModerationService
+}
+
+func NewModerationController(service *ModerationService) *ModerationController {
+	return &ModerationController{service: service}
+}
+
+func (c *ModerationController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.GET("/moderation/queue", guard.Required, c.FindPending)
+	r.POST("/moderation/approve", guard.Required, c.decide(DecisionApprove))
+	r.POST("/moderation/reject", guard.Required, c.decide(DecisionReject))
+	r.POST("/moderation/spam", guard.Required, c.decide(DecisionSpam))
+}
+
+func (c *ModerationController) FindPending(ctx *gin.Context) {
+	itemType := ItemType(ctx.Query("type"))
+	if itemType != "" && !itemType.Valid() {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid type"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var cursor *pagination.Cursor
+	if token := ctx.Query("cursor"); token != "" {
+		decoded, err := pagination.Decode(token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid cursor"})
+			return
+		}
+		cursor = &decoded
+	}
+
+	items, page, apiErr := c.service.FindPending(ctx.Request.Context(), itemType, limit, cursor)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	if link := pagination.LinkHeader(ctx.Request.URL, page); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "pending items found",
+		"items":   items,
+		"next":    page.Next,
+		"prev":    page.Prev,
+	})
+}
+
+func (c *ModerationController) decide(decision Decision) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var dto DecisionDTO
+		if err := ctx.ShouldBindJSON(&dto); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+			return
+		}
+
+		if err := c.service.Decide(ctx.Request.Context(), dto.Type, dto.Id, decision); err != nil {
+			ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": "item moderated",
+			"status":  decision.Status(),
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package moderation
+
+type DecisionDTO struct {
+	Type ItemType `json:"type" binding:"required,oneof=post comment"`
+	Id   string   `json:"id" binding:"required"`
+}
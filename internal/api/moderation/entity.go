@@ -0,0 +1,65 @@
+package moderation
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	StatusSpam     Status = "spam"
+)
+
+type Decision string
+
+const (
+	DecisionApprove Decision = "approve"
+	DecisionReject  Decision = "reject"
+	DecisionSpam    Decision = "spam"
+)
+
+func (d Decision) Status() Status {
+	switch d {
+	case DecisionApprove:
+		return StatusApproved
+	case DecisionSpam:
+		return StatusSpam
+	}
+	return StatusRejected
+}
+
+type ItemType string
+
+const (
+	ItemPost    ItemType = "post"
+	ItemComment ItemType = "comment"
+)
+
+func (t ItemType) Valid() bool {
+	return t == ItemPost || t == ItemComment
+}
+
+type Verdict struct {
+	Status  Status
+	Reasons []string
+	Score   float64
+}
+
+type Item struct {
+	Type      ItemType  `json:"type"`
+	Id        string    `json:"id"`
+	AuthorId  string    `json:"authorId"`
+	Title     *string   `json:"title,omitempty"`
+	Content   string    `json:"content"`
+	Reasons   []string  `json:"reasons"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (i Item) Text() string {
+	if i.Title != nil {
+		return *i.Title + "\n" + i.Content
+	}
+	return i.Content
+}
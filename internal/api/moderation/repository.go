@@ -0,0 +1,200 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type ModerationRepository interface {
+	HasApprovedContent(ctx context.Context, authorId string) (bool, error)
+	Corpus(ctx context.Context, tokens []string) (Corpus, map[string]TokenCount, error)
+	FindPending(ctx context.Context, itemType ItemType, limit int, cursor *pagination.Cursor) ([]Item, error)
+	FindPendingItem(ctx context.Context, itemType ItemType, id string) (Item, error)
+	Decide(ctx context.Context, item Item, decision Decision, moderatorId string, tokens []string) error
+}
+
+type PostgresModerationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresModerationRepository(pool *pgxpool.Pool) *PostgresModerationRepository {
+	return &PostgresModerationRepository{pool: pool}
+}
+
+const pendingItems = `
+		SELECT m.type, m.id, m.author_id, m.title, m.content, m.moderation_reasons, m.spam_score, m.created_at
+		FROM (
+			SELECT 'post' AS type, id, author_id, title, content, moderation_reasons, spam_score, created_at
+			FROM posts
+			WHERE moderation_status = 'pending'
+			UNION ALL
+			SELECT 'comment', id, author_id, NULL, content, moderation_reasons, spam_score, created_at
+			FROM comments
+			WHERE moderation_status = 'pending' AND deleted_at IS NULL
+		) m
+`
+
+func (r *PostgresModerationRepository) HasApprovedContent(ctx context.Context, authorId string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM posts WHERE author_id = $1 AND moderation_status = 'approved')
+		    OR EXISTS (SELECT 1 FROM comments WHERE author_id = $1 AND moderation_status = 'approved')
+	`
+	var exists bool
+	err := r.pool.QueryRow(ctx, query, authorId).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresModerationRepository) Corpus(ctx context.Context, tokens []string) (Corpus, map[string]TokenCount, error) {
+	var corpus Corpus
+	err := r.pool.QueryRow(ctx, `SELECT spam_docs, ham_docs FROM spam_corpus`).Scan(&corpus.SpamDocs, &corpus.HamDocs)
+	if err != nil {
+		return Corpus{}, nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT token, spam_count, ham_count
+		FROM spam_tokens
+		WHERE token = ANY($1)
+	`, tokens)
+	if err != nil {
+		return Corpus{}, nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]TokenCount{}
+	for rows.Next() {
+		var token string
+		var count TokenCount
+		if err := rows.Scan(&token, &count.Spam, &count.Ham); err != nil {
+			return Corpus{}, nil, err
+		}
+		counts[token] = count
+	}
+
+	return corpus, counts, rows.Err()
+}
+
+func (r *PostgresModerationRepository) FindPending(ctx context.Context, itemType ItemType, limit int, cursor *pagination.Cursor) ([]Item, error) {
+	page, args := pagination.Keyset(cursor, "m", 2, limit)
+	query := pendingItems + `
+		WHERE ($1 = '' OR m.type = $1)` + page
+
+	rows, err := r.pool.Query(ctx, query, append([]any{string(itemType)}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *PostgresModerationRepository) FindPendingItem(ctx context.Context, itemType ItemType, id string) (Item, error) {
+	query := pendingItems + `
+		WHERE m.type = $1 AND m.id = $2
+	`
+
+	return scanItem(r.pool.QueryRow(ctx, query, string(itemType), id))
+}
+
+func (r *PostgresModerationRepository) Decide(ctx context.Context, item Item, decision Decision, moderatorId string, tokens []string) error {
+	table := "posts"
+	if item.Type == ItemComment {
+		table = "comments"
+	}
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var id string
+		err := tx.QueryRow(ctx, `
+			UPDATE `+table+`
+			SET moderation_status = $2
+			WHERE id = $1 AND moderation_status = 'pending'
+			RETURNING id
+		`, item.Id, decision.Status()).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		if item.Type == ItemComment && decision == DecisionApprove {
+			_, err = tx.Exec(ctx, `
+				UPDATE posts
+				SET comment_count = comment_count + 1
+				WHERE id = (SELECT post_id FROM comments WHERE id = $1 AND deleted_at IS NULL)
+			`, item.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO moderation_decisions (item_type, item_id, moderator_id, decision, spam_score)
+			VALUES ($1, $2, $3, $4, $5)
+		`, item.Type, item.Id, moderatorId, decision, item.Score)
+		if err != nil {
+			return err
+		}
+
+		// Rejected content is off-topic or abusive, which is neither spam nor
+		// something the classifier should learn to accept.
+		if decision == DecisionReject {
+			return nil
+		}
+		return train(ctx, tx, tokens, decision == DecisionSpam)
+	})
+}
+
+func train(ctx context.Context, tx pgx.Tx, tokens []string, spam bool) error {
+	spamInc, hamInc := 0, 1
+	if spam {
+		spamInc, hamInc = 1, 0
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO spam_tokens (token, spam_count, ham_count)
+		SELECT token, $2, $3 FROM unnest($1::text[]) AS token
+		ON CONFLICT (token) DO UPDATE
+		SET spam_count = spam_tokens.spam_count + EXCLUDED.spam_count,
+		    ham_count = spam_tokens.ham_count + EXCLUDED.ham_count
+	`, tokens, spamInc, hamInc)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE spam_corpus
+		SET spam_docs = spam_docs + $1, ham_docs = ham_docs + $2
+	`, spamInc, hamInc)
+	return err
+}
+
+func scanItem(row pgx.Row) (Item, error) {
+	var item Item
+
+	err := row.Scan(
+		&item.Type,
+		&item.Id,
+		&item.AuthorId,
+		&item.Title,
+		&item.Content,
+		&item.Reasons,
+		&item.Score,
+		&item.CreatedAt,
+	)
+
+	if err != nil {
+		return Item{}, err
+	}
+
+	return item, nil
+}
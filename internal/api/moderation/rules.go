@@ -0,0 +1,46 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Rules struct {
+	FirstTime     bool
+	MaxLinks      int
+	BannedWords   []string
+	SpamThreshold float64
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://|\bwww\.`)
+
+func (r Rules) check(text string, tokens []string, firstTime bool, score float64) []string {
+	reasons := []string{}
+
+	if r.FirstTime && firstTime {
+		reasons = append(reasons, "first-time author")
+	}
+
+	if r.MaxLinks >= 0 {
+		if links := len(linkPattern.FindAllStringIndex(text, -1)); links > r.MaxLinks {
+			reasons = append(reasons, fmt.Sprintf("contains %d links", links))
+		}
+	}
+
+	lower := strings.ToLower(text)
+	for _, word := range r.BannedWords {
+		banned := strings.Contains(word, " ") && strings.Contains(lower, word) ||
+			slices.Contains(tokens, word)
+		if banned {
+			reasons = append(reasons, "banned word: "+word)
+		}
+	}
+
+	if r.SpamThreshold > 0 && score >= r.SpamThreshold {
+		reasons = append(reasons, fmt.Sprintf("spam score %.2f", score))
+	}
+
+	return reasons
+}
@@ -0,0 +1,109 @@
+package moderation
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type ModerationService struct {
+	repository ModerationRepository
+	rules      Rules
+	listeners  []Listener
+}
+
+// Listener is told about decisions on held items once they are stored.
+type Listener interface {
+	ItemDecided(ctx context.Context, item Item, decision Decision)
+}
+
+func NewModerationService(repo ModerationRepository, rules Rules) *ModerationService {
+	return &ModerationService{repository: repo, rules: rules}
+}
+
+func (s *ModerationService) AddListener(listener Listener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *ModerationService) Screen(ctx context.Context, authorId, text string) (Verdict, *errors.ApiError) {
+	if actor, ok := user.FromContext(ctx); ok && actor.CanModerate() {
+		return Verdict{Status: StatusApproved, Reasons: []string{}}, nil
+	}
+
+	tokens := tokenize(text)
+
+	corpus, counts, err := s.repository.Corpus(ctx, tokens)
+	if err != nil {
+		return Verdict{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+	score := spamProbability(corpus, counts, tokens)
+
+	firstTime := false
+	if s.rules.FirstTime {
+		approved, err := s.repository.HasApprovedContent(ctx, authorId)
+		if err != nil {
+			return Verdict{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+		}
+		firstTime = !approved
+	}
+
+	verdict := Verdict{
+		Status:  StatusApproved,
+		Reasons: s.rules.check(text, tokens, firstTime, score),
+		Score:   score,
+	}
+	if len(verdict.Reasons) > 0 {
+		verdict.Status = StatusPending
+	}
+
+	return verdict, nil
+}
+
+func (s *ModerationService) FindPending(ctx context.Context, itemType ItemType, limit int, cursor *pagination.Cursor) ([]Item, pagination.Page, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanModerate); apiErr != nil {
+		return nil, pagination.Page{}, apiErr
+	}
+
+	items, err := s.repository.FindPending(ctx, itemType, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	items, page := pagination.Paginate(items, limit, cursor, func(item Item) (time.Time, string) {
+		return item.CreatedAt, item.Id
+	})
+	return items, page, nil
+}
+
+func (s *ModerationService) Decide(ctx context.Context, itemType ItemType, id string, decision Decision) *errors.ApiError {
+	moderator, apiErr := user.Authorize(ctx, user.User.CanModerate)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	item, err := s.repository.FindPendingItem(ctx, itemType, id)
+	if err != nil {
+		return errors.NewApiError(http.StatusNotFound, "pending item not found")
+	}
+
+	err = s.repository.Decide(ctx, item, decision, moderator.Id, tokenize(item.Text()))
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return errors.NewApiError(http.StatusConflict, "item already moderated")
+	}
+	if err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, listener := range s.listeners {
+		listener.ItemDecided(ctx, item, decision)
+	}
+
+	return nil
+}
@@ -1,10 +1,18 @@
 package post
 
 import (
+	"bytes"
+	"context"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/markdown"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
 )
 
 type PostController struct {
@@ -15,12 +23,24 @@ func NewPostController(service *PostService) *PostController {
 	return &PostController{service: service}
 }
 
-func (c *PostController) RegisterRoutes(r *gin.Engine) {
-	r.POST("/post", c.Create)
-	r.GET("/post", c.FindById)
+func (c *PostController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.POST("/post", guard.Required, c.Create)
+	r.POST("/post/preview", guard.Required, c.Preview)
+	r.GET("/post/highlight.css", c.HighlightStylesheet)
+	r.GET("/post", guard.Optional, c.FindById)
+	r.GET("/posts/:slug", guard.Optional, c.FindBySlug)
 	r.GET("/post/findMany", c.FindMany)
-	r.GET("/post/findAllByAuthor", c.FindAllByAuthor)
-	r.DELETE("/post", c.Delete)
+	r.GET("/post/search", c.Search)
+	r.GET("/post/findAllByAuthor", guard.Optional, c.FindAllByAuthor)
+	r.PATCH("/post", guard.Required, c.Update)
+	r.GET("/post/revisions", guard.Required, c.FindRevisions)
+	r.GET("/post/revision", guard.Required, c.FindRevision)
+	r.GET("/post/revisions/diff", guard.Required, c.DiffRevisions)
+	r.POST("/post/revisions/restore", guard.Required, c.RestoreRevision)
+	r.PATCH("/post/comments", guard.Required, c.LockComments)
+	r.PUT("/post/reaction", guard.Required, c.React)
+	r.DELETE("/post/reaction", guard.Required, c.Unreact)
+	r.DELETE("/post", guard.Required, c.Delete)
 }
 
 func (c *PostController) Create(ctx *gin.Context) {
@@ -31,6 +51,9 @@ func (c *PostController) Create(ctx *gin.Context) {
 		return
 	}
 
+	author, _ := user.CurrentUser(ctx)
+	dto.AuthorId = author.Id
+
 	post, err := c.service.Create(ctx.Request.Context(), dto)
 	if err != nil {
 		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
@@ -43,6 +66,37 @@ func (c *PostController) Create(ctx *gin.Context) {
 	})
 }
 
+func (c *PostController) Preview(ctx *gin.Context) {
+	var dto PreviewPostDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	document, err := c.service.Preview(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "post rendered",
+		"contentHtml": document.HTML,
+		"toc":         document.TOC,
+	})
+}
+
+func (c *PostController) HighlightStylesheet(ctx *gin.Context) {
+	var css bytes.Buffer
+	if err := markdown.Stylesheet(&css); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Data(http.StatusOK, "text/css; charset=utf-8", css.Bytes())
+}
+
 func (c *PostController) FindById(ctx *gin.Context) {
 	id := ctx.Query("id")
 	if id == "" {
@@ -56,36 +110,87 @@ func (c *PostController) FindById(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header("ETag", etag(post.Version))
 	ctx.JSON(http.StatusOK, post)
 }
 
-func (c *PostController) FindMany(ctx *gin.Context) {
-	limitStr := ctx.DefaultQuery("limit", "10")
-	offsetStr := ctx.DefaultQuery("offset", "0")
+func (c *PostController) FindBySlug(ctx *gin.Context) {
+	slug := ctx.Param("slug")
 
-	limit, err := strconv.Atoi(limitStr)
+	post, err := c.service.FindBySlug(ctx.Request.Context(), slug)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
 		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid offset"})
+	if post.Slug != slug {
+		location := "/posts/" + url.PathEscape(post.Slug)
+		if ctx.Request.URL.RawQuery != "" {
+			location += "?" + ctx.Request.URL.RawQuery
+		}
+		ctx.Redirect(http.StatusMovedPermanently, location)
 		return
 	}
 
-	if limit < 1 {
-		limit = 10
+	ctx.Header("ETag", etag(post.Version))
+	ctx.JSON(http.StatusOK, post)
+}
+
+func (c *PostController) FindMany(ctx *gin.Context) {
+	limit, offset, ok := paging(ctx)
+	if !ok {
+		return
 	}
-	if limit > 100 {
-		limit = 100
+
+	filter := PostFilter{
+		Category: ctx.Query("category"),
+		MatchAll: ctx.Query("match") == "all",
 	}
-	if offset < 0 {
-		offset = 0
+	if tags := ctx.Query("tags"); tags != "" {
+		filter.Tags = strings.Split(tags, ",")
+	}
+
+	if _, legacy := ctx.GetQuery("offset"); legacy {
+		posts, apiErr := c.service.FindMany(ctx.Request.Context(), limit, offset, filter)
+		if apiErr != nil {
+			ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": "posts found",
+			"posts":   posts,
+		})
+		return
+	}
+
+	cursor, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+
+	posts, page, apiErr := c.service.FindManyByCursor(ctx.Request.Context(), limit, cursor, filter)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	writePage(ctx, posts, page)
+}
+
+func (c *PostController) Search(ctx *gin.Context) {
+	query := ctx.Query("q")
+	if query == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing query"})
+		return
 	}
 
-	posts, apiErr := c.service.FindMany(ctx.Request.Context(), limit, offset)
+	limit, offset, ok := paging(ctx)
+	if !ok {
+		return
+	}
+
+	results, apiErr := c.service.Search(ctx.Request.Context(), query, ctx.Query("lang"), limit, offset)
 	if apiErr != nil {
 		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
 		return
@@ -93,7 +198,7 @@ func (c *PostController) FindMany(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "posts found",
-		"posts":   posts,
+		"posts":   results,
 	})
 }
 
@@ -104,15 +209,225 @@ func (c *PostController) FindAllByAuthor(ctx *gin.Context) {
 		return
 	}
 
-	posts, apiErr := c.service.FindAllByAuthor(ctx.Request.Context(), author)
+	limit, _, ok := paging(ctx)
+	if !ok {
+		return
+	}
+
+	cursor, ok := cursorParam(ctx)
+	if !ok {
+		return
+	}
+
+	posts, page, apiErr := c.service.FindAllByAuthor(ctx.Request.Context(), author, false, limit, cursor)
 	if apiErr != nil {
 		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
 		return
 	}
 
+	writePage(ctx, posts, page)
+}
+
+func (c *PostController) Update(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	var dto UpdatePostDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	version, ok := parseIfMatch(ctx.GetHeader("If-Match"))
+	if !ok && ctx.GetHeader("If-Match") != "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid If-Match header"})
+		return
+	}
+	if !ok && dto.Version != nil {
+		version, ok = *dto.Version, true
+	}
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{"message": "missing version"})
+		return
+	}
+
+	post, err := c.service.Update(ctx.Request.Context(), id, version, dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.Header("ETag", etag(post.Version))
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "posts found",
-		"posts":   posts,
+		"message": "post updated",
+		"post":    post,
+	})
+}
+
+func (c *PostController) FindRevisions(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	revisions, err := c.service.FindRevisions(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":   "revisions found",
+		"revisions": revisions,
+	})
+}
+
+func (c *PostController) FindRevision(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	version, err := strconv.Atoi(ctx.Query("version"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid version"})
+		return
+	}
+
+	revision, apiErr := c.service.FindRevision(ctx.Request.Context(), id, version)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revision)
+}
+
+func (c *PostController) DiffRevisions(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	from, err := strconv.Atoi(ctx.Query("from"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid from"})
+		return
+	}
+
+	to, err := strconv.Atoi(ctx.Query("to"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid to"})
+		return
+	}
+
+	result, apiErr := c.service.DiffRevisions(ctx.Request.Context(), id, from, to, ctx.DefaultQuery("mode", "line"))
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *PostController) RestoreRevision(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	version, err := strconv.Atoi(ctx.Query("version"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid version"})
+		return
+	}
+
+	var expected *int
+	if header := ctx.GetHeader("If-Match"); header != "" {
+		v, ok := parseIfMatch(header)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid If-Match header"})
+			return
+		}
+		expected = &v
+	}
+
+	post, apiErr := c.service.RestoreRevision(ctx.Request.Context(), id, version, expected)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.Header("ETag", etag(post.Version))
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "revision restored",
+		"post":    post,
+	})
+}
+
+func (c *PostController) LockComments(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	var dto LockCommentsDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	post, err := c.service.LockComments(ctx.Request.Context(), id, *dto.Locked)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "post updated",
+		"post":    post,
+	})
+}
+
+func (c *PostController) React(ctx *gin.Context) {
+	c.changeReaction(ctx, c.service.React, "reaction added")
+}
+
+func (c *PostController) Unreact(ctx *gin.Context) {
+	c.changeReaction(ctx, c.service.Unreact, "reaction removed")
+}
+
+func (c *PostController) changeReaction(ctx *gin.Context, change func(context.Context, string, string) (Reactions, *errors.ApiError), message string) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	reaction := ctx.Query("type")
+	if reaction == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing type"})
+		return
+	}
+
+	reactions, err := change(ctx.Request.Context(), id, reaction)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     message,
+		"reactions":   reactions.Counts,
+		"myReactions": reactions.Mine,
 	})
 }
 
@@ -133,3 +448,79 @@ func (c *PostController) Delete(ctx *gin.Context) {
 		"message": "post deleted",
 	})
 }
+
+func paging(ctx *gin.Context) (int, int, bool) {
+	limitStr := ctx.DefaultQuery("limit", "10")
+	offsetStr := ctx.DefaultQuery("offset", "0")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return 0, 0, false
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid offset"})
+		return 0, 0, false
+	}
+
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset, true
+}
+
+func cursorParam(ctx *gin.Context) (*pagination.Cursor, bool) {
+	token := ctx.Query("cursor")
+	if token == "" {
+		return nil, true
+	}
+
+	cursor, err := pagination.Decode(token)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid cursor"})
+		return nil, false
+	}
+
+	return &cursor, true
+}
+
+func writePage(ctx *gin.Context, posts []Post, page pagination.Page) {
+	if link := pagination.LinkHeader(ctx.Request.URL, page); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "posts found",
+		"posts":   posts,
+		"next":    page.Next,
+		"prev":    page.Prev,
+	})
+}
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func parseIfMatch(header string) (int, bool) {
+	value := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	value = strings.Trim(value, `"`)
+	if value == "" {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
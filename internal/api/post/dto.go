@@ -1,7 +1,72 @@
 package post
 
+import (
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/api/taxonomy"
+	"github.com/joaopdias/blog-server/internal/shared/markdown"
+)
+
 type CreatePostDTO struct {
-	Title     string `json:"title" binding:"required"`
-	Content   string `json:"content" binding:"required"`
-	AuthorId    string `json:"authorId" binding:"required"`
+	Title       string             `json:"title" binding:"required"`
+	Slug        string             `json:"slug"`
+	Content     string             `json:"content" binding:"required"`
+	Status      Status             `json:"status" binding:"omitempty,oneof=draft scheduled published"`
+	PublishedAt *time.Time         `json:"publishedAt"`
+	Tags        []string           `json:"tags" binding:"omitempty,max=20,dive,required"`
+	CategoryId  *string            `json:"categoryId"`
+	Language    *string            `json:"language"`
+	AuthorId    string             `json:"-"`
+	ParsedTags  []taxonomy.Tag     `json:"-"`
+	Moderation  moderation.Verdict `json:"-"`
+
+	ContentHTML   string             `json:"-"`
+	TOC           []markdown.Heading `json:"-"`
+	WordCount     int                `json:"-"`
+	ReadingTime   int                `json:"-"`
+	Excerpt       string             `json:"-"`
+	RenderVersion int                `json:"-"`
+}
+
+type UpdatePostDTO struct {
+	Title       *string    `json:"title,omitempty" binding:"omitempty,min=1"`
+	Slug        *string    `json:"slug,omitempty" binding:"omitempty,min=1"`
+	Content     *string    `json:"content,omitempty" binding:"omitempty,min=1"`
+	Status      *Status    `json:"status,omitempty" binding:"omitempty,oneof=draft scheduled published archived"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	Tags        *[]string  `json:"tags,omitempty" binding:"omitempty,max=20,dive,required"`
+	CategoryId  *string    `json:"categoryId,omitempty"`
+	Language    *string    `json:"language,omitempty"`
+	Version     *int       `json:"version,omitempty" binding:"omitempty,min=1"`
+
+	EditorId      string              `json:"-"`
+	ParsedTags    *[]taxonomy.Tag     `json:"-"`
+	Moderation    *moderation.Verdict `json:"-"`
+	ContentHTML   *string             `json:"-"`
+	TOC           []markdown.Heading  `json:"-"`
+	WordCount     int                 `json:"-"`
+	ReadingTime   int                 `json:"-"`
+	Excerpt       string              `json:"-"`
+	RenderVersion int                 `json:"-"`
+}
+
+type PreviewPostDTO struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type LockCommentsDTO struct {
+	Locked *bool `json:"locked" binding:"required"`
+}
+
+type PostFilter struct {
+	Tags     []string
+	MatchAll bool
+	Category string
+
+	WithContent bool
+
+	FeedOf string
+
+	TagIds []string
 }
@@ -3,14 +3,44 @@ package post
 import (
 	"time"
 
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/api/taxonomy"
 	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/markdown"
 )
 
 type Post struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	AuthorId  string    `json:"authorId"`
-	Author    user.User `json:"author,omitempty"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID               string             `json:"id"`
+	Title            string             `json:"title"`
+	Slug             string             `json:"slug"`
+	Content          string             `json:"content,omitempty"`
+	ContentHTML      string             `json:"contentHtml,omitempty"`
+	TOC              []markdown.Heading `json:"toc,omitempty"`
+	WordCount        int                `json:"wordCount"`
+	ReadingTime      int                `json:"readingTime"`
+	Excerpt          string             `json:"excerpt"`
+	RenderVersion    int                `json:"-"`
+	AuthorId         string             `json:"authorId"`
+	Author           user.User          `json:"author,omitempty"`
+	Status           Status             `json:"status"`
+	PublishedAt      *time.Time         `json:"publishedAt"`
+	CategoryId       *string            `json:"categoryId"`
+	Tags             []taxonomy.Tag     `json:"tags"`
+	Language         string             `json:"language,omitempty"`
+	Version          int                `json:"version"`
+	CommentsLocked   bool               `json:"commentsLocked"`
+	CommentCount     int                `json:"commentCount"`
+	ModerationStatus moderation.Status  `json:"moderationStatus"`
+	Reactions        map[string]int     `json:"reactions"`
+	MyReactions      []string           `json:"myReactions,omitempty"`
+	CreatedAt        time.Time          `json:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt"`
+}
+
+func (p Post) IsPublished() bool {
+	return p.Status == StatusPublished
+}
+
+func (p Post) IsVisible() bool {
+	return p.IsPublished() && p.ModerationStatus == moderation.StatusApproved
 }
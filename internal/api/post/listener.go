@@ -0,0 +1,71 @@
+package post
+
+import (
+	"context"
+	"log"
+
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+)
+
+type Listener interface {
+	PostPublished(ctx context.Context, post Post)
+	PostUpdated(ctx context.Context, post Post)
+	PostDeleted(ctx context.Context, post Post)
+}
+
+func (s *PostService) AddListener(listener Listener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *PostService) notifyPublished(ctx context.Context, posts ...Post) {
+	for _, post := range posts {
+		for _, listener := range s.listeners {
+			listener.PostPublished(ctx, post)
+		}
+	}
+}
+
+// notifyChanged tells listeners how an edit affected a post's public
+// visibility: newly published, edited while published, or withdrawn.
+func (s *PostService) notifyChanged(ctx context.Context, before, after Post) {
+	for _, listener := range s.listeners {
+		switch {
+		case after.IsVisible() && !before.IsVisible():
+			listener.PostPublished(ctx, after)
+		case after.IsVisible():
+			listener.PostUpdated(ctx, after)
+		case before.IsVisible():
+			listener.PostDeleted(ctx, before)
+		}
+	}
+}
+
+func (s *PostService) notifyDeleted(ctx context.Context, post Post) {
+	if !post.IsPublished() {
+		return
+	}
+	for _, listener := range s.listeners {
+		listener.PostDeleted(ctx, post)
+	}
+}
+
+// ItemDecided announces a held post once a moderator approves it, since
+// listeners skipped it while it was pending.
+func (s *PostService) ItemDecided(ctx context.Context, item moderation.Item, decision moderation.Decision) {
+	if item.Type != moderation.ItemPost || decision != moderation.DecisionApprove {
+		return
+	}
+
+	post, err := s.repository.FindById(ctx, item.Id)
+	if err != nil {
+		log.Printf("post: failed to load approved post %s: %s", item.Id, err)
+		return
+	}
+	if !post.IsVisible() {
+		return
+	}
+
+	posts := []Post{post}
+	s.ensureRendered(ctx, posts)
+	s.notifyPublished(ctx, posts...)
+}
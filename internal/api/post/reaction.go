@@ -0,0 +1,6 @@
+package post
+
+type Reactions struct {
+	Counts map[string]int `json:"reactions"`
+	Mine   []string       `json:"myReactions"`
+}
@@ -0,0 +1,50 @@
+package post
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/markdown"
+)
+
+func (s *PostService) Preview(ctx context.Context, previewPostDTO PreviewPostDTO) (markdown.Document, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanWritePosts); apiErr != nil {
+		return markdown.Document{}, apiErr
+	}
+
+	return render(previewPostDTO.Content)
+}
+
+func render(content string) (markdown.Document, *errors.ApiError) {
+	document, err := markdown.Render(content)
+	if err != nil {
+		return markdown.Document{}, errors.NewApiError(http.StatusBadRequest, "failed to render content: "+err.Error())
+	}
+	return document, nil
+}
+
+// ensureRendered re-renders posts whose cached HTML came from an older
+// version of the pipeline and stores the result for the next reader. Posts
+// loaded for listings come without content and are left alone.
+func (s *PostService) ensureRendered(ctx context.Context, posts []Post) {
+	for i := range posts {
+		if posts[i].RenderVersion == markdown.Version || posts[i].Content == "" {
+			continue
+		}
+
+		document, err := markdown.Render(posts[i].Content)
+		if err != nil {
+			log.Printf("post: failed to render post %s: %s", posts[i].ID, err)
+			continue
+		}
+
+		posts[i].ContentHTML, posts[i].TOC, posts[i].RenderVersion = document.HTML, document.TOC, markdown.Version
+		posts[i].WordCount, posts[i].ReadingTime, posts[i].Excerpt = document.WordCount, document.ReadingTime, document.Excerpt
+		if err := s.repository.SaveRendered(ctx, posts[i].ID, posts[i].Version, document, markdown.Version); err != nil {
+			log.Printf("post: failed to cache rendered post %s: %s", posts[i].ID, err)
+		}
+	}
+}
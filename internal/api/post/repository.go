@@ -2,18 +2,41 @@ package post
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/api/taxonomy"
+	"github.com/joaopdias/blog-server/internal/shared/markdown"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
 )
 
 type PostRepository interface {
 	Create(ctx context.Context, createPostDTO CreatePostDTO) (Post, error)
 	FindById(ctx context.Context, id string) (Post, error)
-	FindMany(ctx context.Context, limit, offset int) ([]Post, error)
-	FindAllByAuthor(ctx context.Context, author string) ([]Post, error)
+	FindMany(ctx context.Context, limit, offset int, filter PostFilter) ([]Post, error)
+	FindManyByCursor(ctx context.Context, limit int, cursor *pagination.Cursor, filter PostFilter) ([]Post, error)
+	FindAllByAuthor(ctx context.Context, author string, includeUnpublished, withContent bool, limit int, cursor *pagination.Cursor) ([]Post, error)
+	FindBySlug(ctx context.Context, slug string) (Post, error)
+	Update(ctx context.Context, id string, version int, updatePostDTO UpdatePostDTO) (Post, error)
 	Delete(ctx context.Context, id string) error
+	FindRevisions(ctx context.Context, postId string) ([]Revision, error)
+	FindRevision(ctx context.Context, postId string, version int) (Revision, error)
+	PublishDue(ctx context.Context, limit int) ([]Post, error)
+	Search(ctx context.Context, query, language string, limit, offset int) ([]SearchResult, error)
+	LanguageExists(ctx context.Context, language string) (bool, error)
+	SetCommentsLocked(ctx context.Context, id string, locked bool) (Post, error)
+	AddReaction(ctx context.Context, postId, userId, reaction string) (map[string]int, error)
+	RemoveReaction(ctx context.Context, postId, userId, reaction string) (map[string]int, error)
+	FindReactionsByUser(ctx context.Context, postIds []string, userId string) (map[string][]string, error)
+	SaveRendered(ctx context.Context, id string, version int, document markdown.Document, renderVersion int) error
 }
 
+const postColumns = `id, title, slug, content, content_html, toc, word_count, reading_time, excerpt, render_version, author_id, status, published_at, category_id, language::text, version, comments_locked, comment_count, moderation_status, reaction_counts, created_at, updated_at`
+
 type PostgresPostRepository struct {
 	pool *pgxpool.Pool
 }
@@ -24,19 +47,49 @@ func NewPostgresPostRepository(pool *pgxpool.Pool) *PostgresPostRepository {
 
 func (r *PostgresPostRepository) Create(ctx context.Context, createPostDTO CreatePostDTO) (Post, error) {
 	query := `
-		INSERT INTO posts (title, content, author_id)
-		VALUES ($1, $2, $3)
-		RETURNING id, title, content, author_id, created_at
+		INSERT INTO posts (title, content, author_id, status, published_at, category_id, language, moderation_status, moderation_reasons, spam_score, content_html, toc, word_count, reading_time, excerpt, render_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::regconfig, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id
 	`
 	var post Post
 
-	err := r.pool.QueryRow(ctx, query, createPostDTO.Title, createPostDTO.Content, createPostDTO.AuthorId).Scan(
-		&post.ID,
-		&post.Title,
-		&post.Content,
-		&post.AuthorId,
-		&post.CreatedAt,
-	)
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var id string
+		err := tx.QueryRow(ctx, query,
+			createPostDTO.Title,
+			createPostDTO.Content,
+			createPostDTO.AuthorId,
+			createPostDTO.Status,
+			createPostDTO.PublishedAt,
+			createPostDTO.CategoryId,
+			createPostDTO.Language,
+			createPostDTO.Moderation.Status,
+			createPostDTO.Moderation.Reasons,
+			createPostDTO.Moderation.Score,
+			createPostDTO.ContentHTML,
+			createPostDTO.TOC,
+			createPostDTO.WordCount,
+			createPostDTO.ReadingTime,
+			createPostDTO.Excerpt,
+			createPostDTO.RenderVersion,
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		tags, err := setTags(ctx, tx, id, createPostDTO.ParsedTags)
+		if err != nil {
+			return err
+		}
+
+		post, err = assignSlug(ctx, tx, id, createPostDTO.Slug)
+		if err != nil {
+			return err
+		}
+		post.Tags = tags
+
+		return insertRevision(ctx, tx, post, createPostDTO.AuthorId)
+	})
 
 	if err != nil {
 		return Post{}, err
@@ -47,55 +100,145 @@ func (r *PostgresPostRepository) Create(ctx context.Context, createPostDTO Creat
 
 func (r *PostgresPostRepository) FindById(ctx context.Context, id string) (Post, error) {
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT ` + postColumns + `
 		FROM posts
 		WHERE id = $1
 	`
-	var post Post
 
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&post.ID,
-		&post.Title,
-		&post.Content,
-		&post.AuthorId,
-		&post.CreatedAt,
-	)
+	return scanPost(r.pool.QueryRow(ctx, query, id))
+}
 
-	if err != nil {
-		return Post{}, err
+func (r *PostgresPostRepository) FindBySlug(ctx context.Context, slug string) (Post, error) {
+	query := `
+		SELECT ` + prefixed("p", postColumns) + `
+		FROM post_slugs s
+		JOIN posts p ON p.id = s.post_id
+		WHERE s.slug = $1
+	`
+
+	return scanPost(r.pool.QueryRow(ctx, query, slug))
+}
+
+func (r *PostgresPostRepository) FindMany(ctx context.Context, limit, offset int, filter PostFilter) ([]Post, error) {
+	page := `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $4 OFFSET $5`
+
+	return r.findPublished(ctx, filter, page, limit, offset)
+}
+
+func (r *PostgresPostRepository) FindManyByCursor(ctx context.Context, limit int, cursor *pagination.Cursor, filter PostFilter) ([]Post, error) {
+	alias := "p"
+	if filter.FeedOf != "" {
+		alias = "feed"
 	}
 
-	return post, nil
+	page, args := pagination.Keyset(cursor, alias, 4, limit)
+	return r.findPublished(ctx, filter, page, args...)
 }
 
-func (r *PostgresPostRepository) FindMany(ctx context.Context, limit, offset int) ([]Post, error) {
+// feedPosts lists a user's feed: posts fanned out to feed_items, and posts of
+// followed authors that had too many followers to fan out when published.
+const feedPosts = `(
+			SELECT fi.post_id AS id, fi.created_at
+			FROM feed_items fi
+			WHERE fi.user_id = $%[1]d
+			UNION ALL
+			SELECT mp.id, mp.created_at
+			FROM follows f
+			JOIN posts mp ON mp.author_id = f.followee_id
+			WHERE f.follower_id = $%[1]d AND mp.status = 'published' AND NOT mp.fanned_out
+		) feed
+		JOIN posts p ON p.id = feed.id`
+
+func (r *PostgresPostRepository) findPublished(ctx context.Context, filter PostFilter, page string, pageArgs ...any) ([]Post, error) {
+	var category *string
+	if filter.Category != "" {
+		category = &filter.Category
+	}
+
+	tagIds := filter.TagIds
+	if tagIds == nil {
+		tagIds = []string{}
+	}
+
+	args := append([]any{category, tagIds, filter.MatchAll}, pageArgs...)
+
+	from := `posts p`
+	if filter.FeedOf != "" {
+		args = append(args, filter.FeedOf)
+		from = fmt.Sprintf(feedPosts, len(args))
+	}
+
 	query := `
-		SELECT 
-			p.id, 
-			p.title, 
+		SELECT
+			p.id,
+			p.title,
+			p.slug,
+			` + contentColumns(filter.WithContent) + `,
+			p.word_count,
+			p.reading_time,
+			p.excerpt,
+			p.render_version,
+			p.author_id,
+			p.status,
+			p.published_at,
+			p.version,
+			p.comment_count,
+			p.moderation_status,
+			p.reaction_counts,
 			p.created_at,
+			p.updated_at,
 			u.id,
 			u.name,
-			u.email 
-		FROM posts p
+			u.email
+		FROM ` + from + `
 		JOIN users u ON p.author_id = u.id
-		ORDER BY p.created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		WHERE p.status = 'published'
+		  AND p.moderation_status = 'approved'
+		  AND ($1::text IS NULL OR p.category_id IN (
+			WITH RECURSIVE tree AS (
+				SELECT id FROM categories WHERE slug = $1
+				UNION ALL
+				SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id
+			)
+			SELECT id FROM tree
+		  ))
+		  AND (cardinality($2::uuid[]) = 0 OR (
+			SELECT count(*) FROM post_tags pt
+			WHERE pt.post_id = p.id AND pt.tag_id = ANY($2::uuid[])
+		  ) >= CASE WHEN $3 THEN cardinality($2::uuid[]) ELSE 1 END)` + page
 
 	var posts []Post
 
-	rows, err := r.pool.Query(ctx, query, limit, offset)
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var post Post
 		err := rows.Scan(
 			&post.ID,
 			&post.Title,
+			&post.Slug,
+			&post.Content,
+			&post.ContentHTML,
+			&post.TOC,
+			&post.WordCount,
+			&post.ReadingTime,
+			&post.Excerpt,
+			&post.RenderVersion,
+			&post.AuthorId,
+			&post.Status,
+			&post.PublishedAt,
+			&post.Version,
+			&post.CommentCount,
+			&post.ModerationStatus,
+			&post.Reactions,
 			&post.CreatedAt,
+			&post.UpdatedAt,
 			&post.Author.Id,
 			&post.Author.Name,
 			&post.Author.Email,
@@ -106,32 +249,136 @@ func (r *PostgresPostRepository) FindMany(ctx context.Context, limit, offset int
 		posts = append(posts, post)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
 }
 
-func (r *PostgresPostRepository) FindAllByAuthor(ctx context.Context, author string) ([]Post, error) {
+func (r *PostgresPostRepository) FindAllByAuthor(ctx context.Context, author string, includeUnpublished, withContent bool, limit int, cursor *pagination.Cursor) ([]Post, error) {
+	page, args := pagination.Keyset(cursor, "p", 3, limit)
 	query := `
-		SELECT id, title, created_at
-		FROM posts
-		WHERE author_id = $1
-		ORDER BY created_at DESC
-	`
+		SELECT
+			p.id,
+			p.title,
+			p.slug,
+			` + contentColumns(withContent) + `,
+			p.word_count,
+			p.reading_time,
+			p.excerpt,
+			p.render_version,
+			p.author_id,
+			p.status,
+			p.published_at,
+			p.category_id,
+			p.language::text,
+			p.version,
+			p.comments_locked,
+			p.comment_count,
+			p.moderation_status,
+			p.reaction_counts,
+			p.created_at,
+			p.updated_at
+		FROM posts p
+		WHERE p.author_id = $1
+		  AND ($2 OR (p.status = 'published' AND p.moderation_status = 'approved'))` + page
+
 	var posts []Post
 
-	rows, err := r.pool.Query(ctx, query, author)
+	rows, err := r.pool.Query(ctx, query, append([]any{author, includeUnpublished}, args...)...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var post Post
-		if err := rows.Scan(&post.ID, &post.Title, &post.CreatedAt); err != nil {
+		post, err := scanPost(rows)
+		if err != nil {
 			return nil, err
 		}
 		posts = append(posts, post)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
+}
+
+func (r *PostgresPostRepository) Update(ctx context.Context, id string, version int, updatePostDTO UpdatePostDTO) (Post, error) {
+	query := `
+		UPDATE posts
+		SET title = COALESCE($3, title),
+		    content = COALESCE($4, content),
+		    content_html = COALESCE($9, content_html),
+		    toc = CASE WHEN $9::text IS NULL THEN toc ELSE $10 END,
+		    render_version = CASE WHEN $9::text IS NULL THEN render_version ELSE $11 END,
+		    word_count = CASE WHEN $9::text IS NULL THEN word_count ELSE $12 END,
+		    reading_time = CASE WHEN $9::text IS NULL THEN reading_time ELSE $13 END,
+		    excerpt = CASE WHEN $9::text IS NULL THEN excerpt ELSE $14 END,
+		    status = COALESCE($5, status),
+		    published_at = CASE WHEN $5::text IS NULL THEN published_at ELSE $6 END,
+		    category_id = CASE WHEN $7::text IS NULL THEN category_id ELSE NULLIF($7, '')::uuid END,
+		    language = COALESCE($8::regconfig, language),
+		    moderation_status = COALESCE($15, moderation_status),
+		    moderation_reasons = CASE WHEN $15::text IS NULL THEN moderation_reasons ELSE $16 END,
+		    spam_score = CASE WHEN $15::text IS NULL THEN spam_score ELSE $17 END,
+		    version = version + 1,
+		    updated_at = now()
+		WHERE id = $1 AND version = $2
+		RETURNING ` + postColumns + `
+	`
+	var post Post
+
+	// A held edit goes back to the moderation queue; otherwise the post keeps
+	// its current status.
+	var moderationStatus *moderation.Status
+	var moderationReasons []string
+	var spamScore float64
+	if verdict := updatePostDTO.Moderation; verdict != nil {
+		moderationStatus, moderationReasons, spamScore = &verdict.Status, verdict.Reasons, verdict.Score
+	}
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		post, err = scanPost(tx.QueryRow(ctx, query,
+			id,
+			version,
+			updatePostDTO.Title,
+			updatePostDTO.Content,
+			updatePostDTO.Status,
+			updatePostDTO.PublishedAt,
+			updatePostDTO.CategoryId,
+			updatePostDTO.Language,
+			updatePostDTO.ContentHTML,
+			updatePostDTO.TOC,
+			updatePostDTO.RenderVersion,
+			updatePostDTO.WordCount,
+			updatePostDTO.ReadingTime,
+			updatePostDTO.Excerpt,
+			moderationStatus,
+			moderationReasons,
+			spamScore,
+		))
+		if err != nil {
+			return err
+		}
+
+		if updatePostDTO.ParsedTags != nil {
+			if _, err := setTags(ctx, tx, post.ID, *updatePostDTO.ParsedTags); err != nil {
+				return err
+			}
+		}
+
+		if updatePostDTO.Slug != nil {
+			post, err = assignSlug(ctx, tx, post.ID, *updatePostDTO.Slug)
+			if err != nil {
+				return err
+			}
+		}
+
+		return insertRevision(ctx, tx, post, updatePostDTO.EditorId)
+	})
+
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, nil
 }
 
 func (r *PostgresPostRepository) Delete(ctx context.Context, id string) error {
@@ -142,3 +389,404 @@ func (r *PostgresPostRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.pool.Exec(ctx, query, id)
 	return err
 }
+
+func (r *PostgresPostRepository) FindRevisions(ctx context.Context, postId string) ([]Revision, error) {
+	query := `
+		SELECT id, post_id, version, title, editor_id, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var revision Revision
+		err := rows.Scan(
+			&revision.ID,
+			&revision.PostId,
+			&revision.Version,
+			&revision.Title,
+			&revision.EditorId,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *PostgresPostRepository) FindRevision(ctx context.Context, postId string, version int) (Revision, error) {
+	query := `
+		SELECT id, post_id, version, title, content, editor_id, created_at
+		FROM post_revisions
+		WHERE post_id = $1 AND version = $2
+	`
+	var revision Revision
+
+	err := r.pool.QueryRow(ctx, query, postId, version).Scan(
+		&revision.ID,
+		&revision.PostId,
+		&revision.Version,
+		&revision.Title,
+		&revision.Content,
+		&revision.EditorId,
+		&revision.CreatedAt,
+	)
+
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return revision, nil
+}
+
+func (r *PostgresPostRepository) PublishDue(ctx context.Context, limit int) ([]Post, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM posts
+			WHERE status = 'scheduled' AND published_at <= now()
+			ORDER BY published_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), published AS (
+			UPDATE posts p
+			SET status = 'published', version = p.version + 1, updated_at = now()
+			FROM due
+			WHERE p.id = due.id
+			RETURNING p.*
+		), revisions AS (
+			INSERT INTO post_revisions (post_id, version, title, content, editor_id, created_at)
+			SELECT id, version, title, content, NULL, updated_at
+			FROM published
+		)
+		SELECT ` + postColumns + `
+		FROM published
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func assignSlug(ctx context.Context, tx pgx.Tx, postId, base string) (Post, error) {
+	for attempt := 1; attempt <= maxSlugAttempts; attempt++ {
+		var claimed string
+		err := tx.QueryRow(ctx, `
+			INSERT INTO post_slugs (slug, post_id)
+			VALUES ($1, $2)
+			ON CONFLICT (slug) DO UPDATE SET post_id = EXCLUDED.post_id
+			WHERE post_slugs.post_id = EXCLUDED.post_id
+			RETURNING slug
+		`, slugCandidate(base, attempt), postId).Scan(&claimed)
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Post{}, err
+		}
+
+		return scanPost(tx.QueryRow(ctx, `
+			UPDATE posts
+			SET slug = $2
+			WHERE id = $1
+			RETURNING `+postColumns, postId, claimed))
+	}
+
+	return Post{}, fmt.Errorf("no free slug for %q", base)
+}
+
+func (r *PostgresPostRepository) Search(ctx context.Context, query, language string, limit, offset int) ([]SearchResult, error) {
+	sql := `
+		WITH q AS (
+			SELECT to_tsquery($2::regconfig, $1) AS query
+		), hits AS (
+			SELECT p.id, ts_rank(p.search_vector, q.query) AS rank
+			FROM posts p, q
+			WHERE p.status = 'published'
+			  AND p.moderation_status = 'approved'
+			  AND p.search_vector @@ q.query
+			ORDER BY rank DESC, p.created_at DESC
+			LIMIT $3 OFFSET $4
+		)
+		SELECT
+			p.id,
+			p.title,
+			p.slug,
+			p.status,
+			p.published_at,
+			p.comment_count,
+			p.moderation_status,
+			p.reaction_counts,
+			p.created_at,
+			u.id,
+			u.name,
+			u.email,
+			hits.rank,
+			ts_headline(p.language, translate(p.title, $5, ''), q.query, $6),
+			ts_headline(p.language, translate(p.content, $5, ''), q.query, $7)
+		FROM hits
+		JOIN posts p ON p.id = hits.id
+		JOIN users u ON u.id = p.author_id
+		CROSS JOIN q
+		ORDER BY hits.rank DESC, p.created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, sql, query, language, limit, offset, markStart+markStop, headlineOptions, snippetOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(
+			&result.ID,
+			&result.Title,
+			&result.Slug,
+			&result.Status,
+			&result.PublishedAt,
+			&result.CommentCount,
+			&result.ModerationStatus,
+			&result.Reactions,
+			&result.CreatedAt,
+			&result.Author.Id,
+			&result.Author.Name,
+			&result.Author.Email,
+			&result.Rank,
+			&result.Headline,
+			&result.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result.Headline, result.Snippet = highlight(result.Headline), highlight(result.Snippet)
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *PostgresPostRepository) LanguageExists(ctx context.Context, language string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = $1)
+	`
+	var exists bool
+	err := r.pool.QueryRow(ctx, query, language).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresPostRepository) SetCommentsLocked(ctx context.Context, id string, locked bool) (Post, error) {
+	query := `
+		UPDATE posts
+		SET comments_locked = $2
+		WHERE id = $1
+		RETURNING ` + postColumns + `
+	`
+
+	return scanPost(r.pool.QueryRow(ctx, query, id, locked))
+}
+
+func (r *PostgresPostRepository) AddReaction(ctx context.Context, postId, userId, reaction string) (map[string]int, error) {
+	query := `
+		WITH added AS (
+			INSERT INTO post_reactions (post_id, user_id, type)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+			RETURNING post_id
+		)
+		UPDATE posts
+		SET reaction_counts = jsonb_set(
+			reaction_counts,
+			ARRAY[$3::text],
+			to_jsonb(COALESCE((reaction_counts ->> $3::text)::int, 0) + 1)
+		)
+		WHERE id IN (SELECT post_id FROM added)
+		RETURNING reaction_counts
+	`
+
+	return r.reactionCounts(ctx, postId, query, postId, userId, reaction)
+}
+
+func (r *PostgresPostRepository) RemoveReaction(ctx context.Context, postId, userId, reaction string) (map[string]int, error) {
+	query := `
+		WITH removed AS (
+			DELETE FROM post_reactions
+			WHERE post_id = $1 AND user_id = $2 AND type = $3
+			RETURNING post_id
+		)
+		UPDATE posts
+		SET reaction_counts = CASE
+			WHEN COALESCE((reaction_counts ->> $3::text)::int, 0) <= 1 THEN reaction_counts - $3::text
+			ELSE jsonb_set(reaction_counts, ARRAY[$3::text], to_jsonb((reaction_counts ->> $3::text)::int - 1))
+		END
+		WHERE id IN (SELECT post_id FROM removed)
+		RETURNING reaction_counts
+	`
+
+	return r.reactionCounts(ctx, postId, query, postId, userId, reaction)
+}
+
+// reactionCounts runs an add or remove statement and falls back to the
+// stored counts when it changed nothing, which keeps both operations
+// idempotent.
+func (r *PostgresPostRepository) reactionCounts(ctx context.Context, postId, query string, args ...any) (map[string]int, error) {
+	var counts map[string]int
+	err := r.pool.QueryRow(ctx, query, args...).Scan(&counts)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		err = r.pool.QueryRow(ctx, `SELECT reaction_counts FROM posts WHERE id = $1`, postId).Scan(&counts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (r *PostgresPostRepository) FindReactionsByUser(ctx context.Context, postIds []string, userId string) (map[string][]string, error) {
+	query := `
+		SELECT post_id, type
+		FROM post_reactions
+		WHERE post_id = ANY($1::uuid[]) AND user_id = $2
+		ORDER BY created_at
+	`
+
+	rows, err := r.pool.Query(ctx, query, postIds, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := map[string][]string{}
+	for rows.Next() {
+		var postId, reaction string
+		if err := rows.Scan(&postId, &reaction); err != nil {
+			return nil, err
+		}
+		reactions[postId] = append(reactions[postId], reaction)
+	}
+
+	return reactions, rows.Err()
+}
+
+// SaveRendered caches HTML rendered for a post, unless the post was edited
+// since it was read.
+func (r *PostgresPostRepository) SaveRendered(ctx context.Context, id string, version int, document markdown.Document, renderVersion int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE posts
+		SET content_html = $3, toc = $4, word_count = $5, reading_time = $6, excerpt = $7, render_version = $8
+		WHERE id = $1 AND version = $2
+	`, id, version, document.HTML, document.TOC, document.WordCount, document.ReadingTime, document.Excerpt, renderVersion)
+	return err
+}
+
+func setTags(ctx context.Context, tx pgx.Tx, postId string, parsed []taxonomy.Tag) ([]taxonomy.Tag, error) {
+	tags, err := taxonomy.EnsureTags(ctx, tx, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	tagIds := make([]string, len(tags))
+	for i, tag := range tags {
+		tagIds[i] = tag.Id
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM post_tags
+		WHERE post_id = $1 AND NOT (tag_id = ANY($2::uuid[]))
+	`, postId, tagIds)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO post_tags (post_id, tag_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, postId, tagIds)
+	return tags, err
+}
+
+func insertRevision(ctx context.Context, tx pgx.Tx, post Post, editorId string) error {
+	query := `
+		INSERT INTO post_revisions (post_id, version, title, content, editor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := tx.Exec(ctx, query, post.ID, post.Version, post.Title, post.Content, editorId, post.UpdatedAt)
+	return err
+}
+
+func scanPost(row pgx.Row) (Post, error) {
+	var post Post
+
+	err := row.Scan(
+		&post.ID,
+		&post.Title,
+		&post.Slug,
+		&post.Content,
+		&post.ContentHTML,
+		&post.TOC,
+		&post.WordCount,
+		&post.ReadingTime,
+		&post.Excerpt,
+		&post.RenderVersion,
+		&post.AuthorId,
+		&post.Status,
+		&post.PublishedAt,
+		&post.CategoryId,
+		&post.Language,
+		&post.Version,
+		&post.CommentsLocked,
+		&post.CommentCount,
+		&post.ModerationStatus,
+		&post.Reactions,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+	)
+
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, nil
+}
+
+// contentColumns selects the full content of p, or empty stand-ins for
+// listings that only show the excerpt.
+func contentColumns(withContent bool) string {
+	if withContent {
+		return `p.content, p.content_html, p.toc`
+	}
+	return `'', '', NULL::jsonb`
+}
+
+func prefixed(alias, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, column := range parts {
+		parts[i] = alias + "." + column
+	}
+	return strings.Join(parts, ", ")
+}
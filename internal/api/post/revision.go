@@ -0,0 +1,25 @@
+package post
+
+import (
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/shared/diff"
+)
+
+type Revision struct {
+	ID        string    `json:"id"`
+	PostId    string    `json:"postId"`
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content,omitempty"`
+	EditorId  *string   `json:"editorId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RevisionDiff struct {
+	From    int          `json:"from"`
+	To      int          `json:"to"`
+	Mode    string       `json:"mode"`
+	Title   []diff.Chunk `json:"title"`
+	Content []diff.Chunk `json:"content"`
+}
@@ -0,0 +1,47 @@
+package post
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const publishBatchSize = 100
+
+type Scheduler struct {
+	service  *PostService
+	interval time.Duration
+}
+
+func NewScheduler(service *PostService, interval time.Duration) *Scheduler {
+	return &Scheduler{service: service, interval: interval}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	for {
+		posts, err := s.service.PublishDue(ctx)
+		if err != nil {
+			log.Printf("scheduler: failed to publish due posts: %s", err.Message)
+			return
+		}
+
+		if len(posts) < publishBatchSize {
+			return
+		}
+	}
+}
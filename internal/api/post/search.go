@@ -0,0 +1,146 @@
+package post
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// ts_headline copies the post text verbatim, so matches are wrapped in control
+// characters instead of markup and the text is escaped before they become
+// <mark> elements. The characters are removed from the text beforehand.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+
+	headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", HighlightAll=true"
+	snippetOptions  = "StartSel=" + markStart + ", StopSel=" + markStop + `, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`
+)
+
+type SearchResult struct {
+	Post
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+	Snippet  string  `json:"snippet"`
+}
+
+func highlight(fragment string) string {
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(html.EscapeString(fragment))
+}
+
+// toTsQuery turns a user query into to_tsquery syntax. Bare words are ANDed,
+// "quoted phrases" become <-> chains, a trailing * asks for a prefix match,
+// a leading - negates a term and OR between terms switches to |.
+func toTsQuery(input string) string {
+	var terms []string
+	var ops []string
+	pendingOr := false
+
+	for _, token := range tokenize(input) {
+		if !token.phrase && strings.EqualFold(token.text, "or") {
+			pendingOr = len(terms) > 0
+			continue
+		}
+
+		term := token.compile()
+		if term == "" {
+			continue
+		}
+
+		if len(terms) > 0 {
+			if pendingOr {
+				ops = append(ops, " | ")
+			} else {
+				ops = append(ops, " & ")
+			}
+		}
+		pendingOr = false
+		terms = append(terms, term)
+	}
+
+	var b strings.Builder
+	for i, term := range terms {
+		if i > 0 {
+			b.WriteString(ops[i-1])
+		}
+		b.WriteString(term)
+	}
+	return b.String()
+}
+
+type queryToken struct {
+	text    string
+	phrase  bool
+	negated bool
+	prefix  bool
+}
+
+func (t queryToken) compile() string {
+	var lexemes []string
+	for _, word := range strings.FieldsFunc(t.text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		lexemes = append(lexemes, "'"+word+"'")
+	}
+
+	if len(lexemes) == 0 {
+		return ""
+	}
+
+	if t.prefix {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+
+	term := strings.Join(lexemes, " <-> ")
+	if len(lexemes) > 1 {
+		term = "(" + term + ")"
+	}
+	if t.negated {
+		term = "!" + term
+	}
+	return term
+}
+
+func tokenize(input string) []queryToken {
+	var tokens []queryToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		token := queryToken{}
+		if runes[i] == '-' {
+			token.negated = true
+			i++
+		}
+
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			token.text = string(runes[i+1 : end])
+			token.phrase = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			token.text = string(runes[i:end])
+			i = end
+		}
+
+		if strings.HasSuffix(token.text, "*") {
+			token.prefix = true
+			token.text = strings.TrimRight(token.text, "*")
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
@@ -2,32 +2,109 @@ package post
 
 import (
 	"context"
+	stderrors "errors"
 	"net/http"
+	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+
+	"github.com/joaopdias/blog-server/internal/api/moderation"
+	"github.com/joaopdias/blog-server/internal/api/taxonomy"
 	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/diff"
 	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/markdown"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
 )
 
 type PostService struct {
-	repository  PostRepository
-	userService *user.UserService
+	repository      PostRepository
+	userService     *user.UserService
+	taxonomyService *taxonomy.TaxonomyService
+	moderation      *moderation.ModerationService
+	searchLanguage  string
+	reactionTypes   []string
+	listeners       []Listener
 }
 
-func NewPostService(repo PostRepository, userService *user.UserService) *PostService {
-	return &PostService{repository: repo, userService: userService}
+func NewPostService(repo PostRepository, userService *user.UserService, taxonomyService *taxonomy.TaxonomyService, moderationService *moderation.ModerationService, searchLanguage string, reactionTypes []string) *PostService {
+	return &PostService{
+		repository:      repo,
+		userService:     userService,
+		taxonomyService: taxonomyService,
+		moderation:      moderationService,
+		searchLanguage:  searchLanguage,
+		reactionTypes:   reactionTypes,
+	}
 }
 
 func (s *PostService) Create(ctx context.Context, createPostDTO CreatePostDTO) (Post, *errors.ApiError) {
-	_, apiErr := s.userService.FindById(ctx, createPostDTO.AuthorId)
+	_, apiErr := user.Authorize(ctx, user.User.CanWritePosts)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	_, apiErr = s.userService.FindById(ctx, createPostDTO.AuthorId)
 	if apiErr != nil {
 		return Post{}, errors.NewApiError(http.StatusBadRequest, "author does not exist")
 	}
 
+	if createPostDTO.Status == "" {
+		createPostDTO.Status = StatusPublished
+	}
+
+	publishedAt, invalid := publishedAtFor(createPostDTO.Status, createPostDTO.PublishedAt, nil, time.Now())
+	if invalid != "" {
+		return Post{}, errors.NewApiError(http.StatusBadRequest, invalid)
+	}
+	createPostDTO.PublishedAt = publishedAt
+
+	if createPostDTO.Slug != "" {
+		createPostDTO.Slug = slugify(createPostDTO.Slug)
+	} else {
+		createPostDTO.Slug = slugify(createPostDTO.Title)
+	}
+
+	if apiErr := s.checkCategory(ctx, createPostDTO.CategoryId); apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	if createPostDTO.Language == nil {
+		createPostDTO.Language = &s.searchLanguage
+	}
+	if apiErr := s.checkLanguage(ctx, *createPostDTO.Language); apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	tags, apiErr := s.taxonomyService.ParseTags(createPostDTO.Tags)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+	createPostDTO.ParsedTags = tags
+
+	verdict, apiErr := s.moderation.Screen(ctx, createPostDTO.AuthorId, createPostDTO.Title+"\n"+createPostDTO.Content)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+	createPostDTO.Moderation = verdict
+
+	document, apiErr := render(createPostDTO.Content)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+	createPostDTO.ContentHTML, createPostDTO.TOC, createPostDTO.RenderVersion = document.HTML, document.TOC, markdown.Version
+	createPostDTO.WordCount, createPostDTO.ReadingTime, createPostDTO.Excerpt = document.WordCount, document.ReadingTime, document.Excerpt
+
 	post, err := s.repository.Create(ctx, createPostDTO)
 	if err != nil {
 		return Post{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
+	if post.IsPublished() {
+		s.notifyPublished(ctx, post)
+	}
+
 	return post, nil
 }
 
@@ -37,32 +114,470 @@ func (s *PostService) FindById(ctx context.Context, id string) (Post, *errors.Ap
 		return Post{}, errors.NewApiError(http.StatusNotFound, "post not found")
 	}
 
-	return post, nil
+	if !post.IsVisible() && !canManage(ctx, post.AuthorId) {
+		return Post{}, errors.NewApiError(http.StatusNotFound, "post not found")
+	}
+
+	return s.withRelations(ctx, post)
 }
 
-func (s *PostService) FindMany(ctx context.Context, limit, offset int) ([]Post, *errors.ApiError) {
-	posts, err := s.repository.FindMany(ctx, limit, offset)
+func (s *PostService) FindBySlug(ctx context.Context, slug string) (Post, *errors.ApiError) {
+	post, err := s.repository.FindBySlug(ctx, slug)
+	if err != nil {
+		return Post{}, errors.NewApiError(http.StatusNotFound, "post not found")
+	}
+
+	if !post.IsVisible() && !canManage(ctx, post.AuthorId) {
+		return Post{}, errors.NewApiError(http.StatusNotFound, "post not found")
+	}
+
+	return s.withRelations(ctx, post)
+}
+
+func (s *PostService) FindMany(ctx context.Context, limit, offset int, filter PostFilter) ([]Post, *errors.ApiError) {
+	filter, found, apiErr := s.resolveFilter(ctx, filter)
+	if apiErr != nil || !found {
+		return []Post{}, apiErr
+	}
+
+	posts, err := s.repository.FindMany(ctx, limit, offset, filter)
 	if err != nil {
 		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
-	return posts, nil
+	return s.withManyRelations(ctx, posts)
+}
+
+func (s *PostService) FindManyByCursor(ctx context.Context, limit int, cursor *pagination.Cursor, filter PostFilter) ([]Post, pagination.Page, *errors.ApiError) {
+	filter, found, apiErr := s.resolveFilter(ctx, filter)
+	if apiErr != nil || !found {
+		return []Post{}, pagination.Page{}, apiErr
+	}
+
+	posts, err := s.repository.FindManyByCursor(ctx, limit, cursor, filter)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	posts, page := pagination.Paginate(posts, limit, cursor, postKey)
+	posts, apiErr = s.withManyRelations(ctx, posts)
+	return posts, page, apiErr
 }
 
-func (s *PostService) FindAllByAuthor(ctx context.Context, author string) ([]Post, *errors.ApiError) {
-	posts, err := s.repository.FindAllByAuthor(ctx, author)
+func (s *PostService) Search(ctx context.Context, query, language string, limit, offset int) ([]SearchResult, *errors.ApiError) {
+	tsQuery := toTsQuery(query)
+	if tsQuery == "" {
+		return nil, errors.NewApiError(http.StatusBadRequest, "missing query")
+	}
+
+	if language == "" {
+		language = s.searchLanguage
+	}
+	if apiErr := s.checkLanguage(ctx, language); apiErr != nil {
+		return nil, apiErr
+	}
+
+	results, err := s.repository.Search(ctx, tsQuery, language, limit, offset)
 	if err != nil {
 		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
-	return posts, nil
+	return results, nil
+}
+
+func (s *PostService) FindAllByAuthor(ctx context.Context, author string, withContent bool, limit int, cursor *pagination.Cursor) ([]Post, pagination.Page, *errors.ApiError) {
+	posts, err := s.repository.FindAllByAuthor(ctx, author, canManage(ctx, author), withContent, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	posts, page := pagination.Paginate(posts, limit, cursor, postKey)
+	posts, apiErr := s.withManyRelations(ctx, posts)
+	return posts, page, apiErr
+}
+
+func (s *PostService) Update(ctx context.Context, id string, version int, updatePostDTO UpdatePostDTO) (Post, *errors.ApiError) {
+	if updatePostDTO.Title == nil && updatePostDTO.Content == nil && updatePostDTO.Status == nil &&
+		updatePostDTO.Slug == nil && updatePostDTO.Tags == nil && updatePostDTO.CategoryId == nil &&
+		updatePostDTO.Language == nil {
+		return Post{}, errors.NewApiError(http.StatusBadRequest, "nothing to update")
+	}
+
+	current, apiErr := s.FindById(ctx, id)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	actor, apiErr := user.Authorize(ctx, func(u user.User) bool { return u.CanManagePost(current.AuthorId) })
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	if current.Version != version {
+		return Post{}, errors.NewApiError(http.StatusConflict, "post was modified by someone else")
+	}
+
+	if updatePostDTO.Status != nil {
+		publishedAt, invalid := publishedAtFor(*updatePostDTO.Status, updatePostDTO.PublishedAt, current.PublishedAt, time.Now())
+		if invalid != "" {
+			return Post{}, errors.NewApiError(http.StatusBadRequest, invalid)
+		}
+		updatePostDTO.PublishedAt = publishedAt
+	}
+
+	switch {
+	case updatePostDTO.Slug != nil:
+		slug := slugify(*updatePostDTO.Slug)
+		updatePostDTO.Slug = &slug
+	case updatePostDTO.Title != nil && *updatePostDTO.Title != current.Title:
+		slug := slugify(*updatePostDTO.Title)
+		updatePostDTO.Slug = &slug
+	}
+
+	if updatePostDTO.CategoryId != nil && *updatePostDTO.CategoryId != "" {
+		if apiErr := s.checkCategory(ctx, updatePostDTO.CategoryId); apiErr != nil {
+			return Post{}, apiErr
+		}
+	}
+
+	if updatePostDTO.Language != nil {
+		if apiErr := s.checkLanguage(ctx, *updatePostDTO.Language); apiErr != nil {
+			return Post{}, apiErr
+		}
+	}
+
+	if updatePostDTO.Tags != nil {
+		tags, apiErr := s.taxonomyService.ParseTags(*updatePostDTO.Tags)
+		if apiErr != nil {
+			return Post{}, apiErr
+		}
+		updatePostDTO.ParsedTags = &tags
+	}
+
+	title, content := current.Title, current.Content
+	if updatePostDTO.Title != nil {
+		title = *updatePostDTO.Title
+	}
+	if updatePostDTO.Content != nil {
+		content = *updatePostDTO.Content
+	}
+	if title != current.Title || content != current.Content {
+		verdict, apiErr := s.moderation.Screen(ctx, current.AuthorId, title+"\n"+content)
+		if apiErr != nil {
+			return Post{}, apiErr
+		}
+		if verdict.Status != moderation.StatusApproved {
+			updatePostDTO.Moderation = &verdict
+		}
+	}
+
+	if updatePostDTO.Content != nil {
+		document, apiErr := render(*updatePostDTO.Content)
+		if apiErr != nil {
+			return Post{}, apiErr
+		}
+		updatePostDTO.ContentHTML, updatePostDTO.TOC, updatePostDTO.RenderVersion = &document.HTML, document.TOC, markdown.Version
+		updatePostDTO.WordCount, updatePostDTO.ReadingTime, updatePostDTO.Excerpt = document.WordCount, document.ReadingTime, document.Excerpt
+	}
+
+	updatePostDTO.EditorId = actor.Id
+
+	post, err := s.repository.Update(ctx, id, version, updatePostDTO)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return Post{}, errors.NewApiError(http.StatusConflict, "post was modified by someone else")
+	}
+	if err != nil {
+		return Post{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	s.notifyChanged(ctx, current, post)
+
+	return s.withRelations(ctx, post)
 }
 
 func (s *PostService) Delete(ctx context.Context, id string) *errors.ApiError {
+	post, apiErr := s.FindById(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	_, apiErr = user.Authorize(ctx, func(u user.User) bool { return u.CanManagePost(post.AuthorId) })
+	if apiErr != nil {
+		return apiErr
+	}
+
 	err := s.repository.Delete(ctx, id)
 	if err != nil {
 		return errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
+	s.notifyDeleted(ctx, post)
+
+	return nil
+}
+
+func (s *PostService) LockComments(ctx context.Context, id string, locked bool) (Post, *errors.ApiError) {
+	if _, apiErr := s.findManageable(ctx, id); apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	post, err := s.repository.SetCommentsLocked(ctx, id, locked)
+	if err != nil {
+		return Post{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return s.withRelations(ctx, post)
+}
+
+func (s *PostService) React(ctx context.Context, id, reaction string) (Reactions, *errors.ApiError) {
+	return s.changeReaction(ctx, id, reaction, s.repository.AddReaction)
+}
+
+func (s *PostService) Unreact(ctx context.Context, id, reaction string) (Reactions, *errors.ApiError) {
+	return s.changeReaction(ctx, id, reaction, s.repository.RemoveReaction)
+}
+
+func (s *PostService) changeReaction(ctx context.Context, id, reaction string, change func(context.Context, string, string, string) (map[string]int, error)) (Reactions, *errors.ApiError) {
+	actor, apiErr := user.Authorize(ctx, user.User.CanReact)
+	if apiErr != nil {
+		return Reactions{}, apiErr
+	}
+
+	if !slices.Contains(s.reactionTypes, reaction) {
+		return Reactions{}, errors.NewApiError(http.StatusBadRequest, "unknown reaction")
+	}
+
+	post, err := s.repository.FindById(ctx, id)
+	if err != nil || !post.IsVisible() {
+		return Reactions{}, errors.NewApiError(http.StatusNotFound, "post not found")
+	}
+
+	counts, err := change(ctx, id, actor.Id, reaction)
+	if err != nil {
+		return Reactions{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	mine, err := s.repository.FindReactionsByUser(ctx, []string{id}, actor.Id)
+	if err != nil {
+		return Reactions{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	result := Reactions{Counts: counts, Mine: mine[id]}
+	if result.Mine == nil {
+		result.Mine = []string{}
+	}
+
+	return result, nil
+}
+
+func (s *PostService) FindRevisions(ctx context.Context, id string) ([]Revision, *errors.ApiError) {
+	if _, apiErr := s.findManageable(ctx, id); apiErr != nil {
+		return nil, apiErr
+	}
+
+	revisions, err := s.repository.FindRevisions(ctx, id)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return revisions, nil
+}
+
+func (s *PostService) FindRevision(ctx context.Context, id string, version int) (Revision, *errors.ApiError) {
+	if _, apiErr := s.findManageable(ctx, id); apiErr != nil {
+		return Revision{}, apiErr
+	}
+
+	return s.findRevision(ctx, id, version)
+}
+
+func (s *PostService) DiffRevisions(ctx context.Context, id string, from, to int, mode string) (RevisionDiff, *errors.ApiError) {
+	var compare func(a, b string) []diff.Chunk
+	switch mode {
+	case "line":
+		compare = diff.Lines
+	case "word":
+		compare = diff.Words
+	default:
+		return RevisionDiff{}, errors.NewApiError(http.StatusBadRequest, "invalid mode")
+	}
+
+	if _, apiErr := s.findManageable(ctx, id); apiErr != nil {
+		return RevisionDiff{}, apiErr
+	}
+
+	older, apiErr := s.findRevision(ctx, id, from)
+	if apiErr != nil {
+		return RevisionDiff{}, apiErr
+	}
+
+	newer, apiErr := s.findRevision(ctx, id, to)
+	if apiErr != nil {
+		return RevisionDiff{}, apiErr
+	}
+
+	return RevisionDiff{
+		From:    from,
+		To:      to,
+		Mode:    mode,
+		Title:   diff.Words(older.Title, newer.Title),
+		Content: compare(older.Content, newer.Content),
+	}, nil
+}
+
+func (s *PostService) RestoreRevision(ctx context.Context, id string, version int, expectedVersion *int) (Post, *errors.ApiError) {
+	current, apiErr := s.findManageable(ctx, id)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	revision, apiErr := s.findRevision(ctx, id, version)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	base := current.Version
+	if expectedVersion != nil {
+		base = *expectedVersion
+	}
+
+	return s.Update(ctx, id, base, UpdatePostDTO{
+		Title:   &revision.Title,
+		Content: &revision.Content,
+	})
+}
+
+func (s *PostService) PublishDue(ctx context.Context) ([]Post, *errors.ApiError) {
+	posts, err := s.repository.PublishDue(ctx, publishBatchSize)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	s.ensureRendered(ctx, posts)
+	s.notifyPublished(ctx, posts...)
+
+	return posts, nil
+}
+
+func (s *PostService) findManageable(ctx context.Context, id string) (Post, *errors.ApiError) {
+	post, apiErr := s.FindById(ctx, id)
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	_, apiErr = user.Authorize(ctx, func(u user.User) bool { return u.CanManagePost(post.AuthorId) })
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	return post, nil
+}
+
+func (s *PostService) findRevision(ctx context.Context, id string, version int) (Revision, *errors.ApiError) {
+	revision, err := s.repository.FindRevision(ctx, id, version)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return Revision{}, errors.NewApiError(http.StatusNotFound, "revision not found")
+	}
+	if err != nil {
+		return Revision{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return revision, nil
+}
+
+func (s *PostService) resolveFilter(ctx context.Context, filter PostFilter) (PostFilter, bool, *errors.ApiError) {
+	if len(filter.Tags) == 0 {
+		return filter, true, nil
+	}
+
+	ids, unknown, apiErr := s.taxonomyService.ResolveTagIds(ctx, filter.Tags)
+	if apiErr != nil {
+		return filter, false, apiErr
+	}
+	if len(ids) == 0 || (filter.MatchAll && unknown > 0) {
+		return filter, false, nil
+	}
+
+	filter.TagIds = ids
+	return filter, true, nil
+}
+
+func postKey(post Post) (time.Time, string) {
+	return post.CreatedAt, post.ID
+}
+
+func canManage(ctx context.Context, authorId string) bool {
+	viewer, ok := user.FromContext(ctx)
+	return ok && viewer.CanManagePost(authorId)
+}
+
+func (s *PostService) checkCategory(ctx context.Context, categoryId *string) *errors.ApiError {
+	if categoryId == nil {
+		return nil
+	}
+
+	if _, apiErr := s.taxonomyService.FindCategoryById(ctx, *categoryId); apiErr != nil {
+		return errors.NewApiError(http.StatusBadRequest, "category does not exist")
+	}
+
+	return nil
+}
+
+func (s *PostService) checkLanguage(ctx context.Context, language string) *errors.ApiError {
+	exists, err := s.repository.LanguageExists(ctx, language)
+	if err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	if !exists {
+		return errors.NewApiError(http.StatusBadRequest, "unsupported language")
+	}
+
 	return nil
 }
+
+func (s *PostService) withRelations(ctx context.Context, post Post) (Post, *errors.ApiError) {
+	posts, apiErr := s.withManyRelations(ctx, []Post{post})
+	if apiErr != nil {
+		return Post{}, apiErr
+	}
+
+	return posts[0], nil
+}
+
+func (s *PostService) withManyRelations(ctx context.Context, posts []Post) ([]Post, *errors.ApiError) {
+	s.ensureRendered(ctx, posts)
+
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	tags, apiErr := s.taxonomyService.TagsByPosts(ctx, ids)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	for i := range posts {
+		posts[i].Tags = tags[posts[i].ID]
+		if posts[i].Tags == nil {
+			posts[i].Tags = []taxonomy.Tag{}
+		}
+	}
+
+	viewer, ok := user.FromContext(ctx)
+	if !ok || len(posts) == 0 {
+		return posts, nil
+	}
+
+	reactions, err := s.repository.FindReactionsByUser(ctx, ids, viewer.Id)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	for i := range posts {
+		posts[i].MyReactions = reactions[posts[i].ID]
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,35 @@
+package post
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gosimple/slug"
+)
+
+const (
+	maxSlugLength   = 80
+	maxSlugAttempts = 50
+)
+
+func slugify(text string) string {
+	s := slug.Make(text)
+	if len(s) > maxSlugLength {
+		s = s[:maxSlugLength]
+		if i := strings.LastIndex(s, "-"); i > maxSlugLength/2 {
+			s = s[:i]
+		}
+	}
+	s = strings.Trim(s, "-")
+	if s == "" {
+		return "post"
+	}
+	return s
+}
+
+func slugCandidate(base string, attempt int) string {
+	if attempt == 1 {
+		return base
+	}
+	return base + "-" + strconv.Itoa(attempt)
+}
@@ -0,0 +1,35 @@
+package post
+
+import "time"
+
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusScheduled Status = "scheduled"
+	StatusPublished Status = "published"
+	StatusArchived  Status = "archived"
+)
+
+func publishedAtFor(status Status, requested, current *time.Time, now time.Time) (*time.Time, string) {
+	switch status {
+	case StatusDraft:
+		return nil, ""
+	case StatusScheduled:
+		if requested == nil || !requested.After(now) {
+			return nil, "scheduled posts need a future publishedAt"
+		}
+		return requested, ""
+	case StatusPublished:
+		if requested != nil && !requested.After(now) {
+			return requested, ""
+		}
+		if current != nil && !current.After(now) {
+			return current, ""
+		}
+		return &now, ""
+	case StatusArchived:
+		return current, ""
+	}
+	return nil, "invalid status"
+}
@@ -1,41 +1,149 @@
 package api
 
 import (
+	"context"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaopdias/blog-server/internal/api/activitypub"
+	"github.com/joaopdias/blog-server/internal/api/comment"
+	"github.com/joaopdias/blog-server/internal/api/feed"
+	"github.com/joaopdias/blog-server/internal/api/media"
+	"github.com/joaopdias/blog-server/internal/api/micropub"
+	"github.com/joaopdias/blog-server/internal/api/moderation"
 	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/sitemap"
+	"github.com/joaopdias/blog-server/internal/api/syndication"
+	"github.com/joaopdias/blog-server/internal/api/taxonomy"
 	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/api/webmention"
+	"github.com/joaopdias/blog-server/internal/api/wellknown"
+	"github.com/joaopdias/blog-server/internal/api/xmlrpc"
+	"github.com/joaopdias/blog-server/internal/config"
+	"github.com/joaopdias/blog-server/internal/shared/auth"
 )
 
 type services struct {
+	activityPub    *activitypub.ActivityPubController
+	apDelivery     *activitypub.DeliveryWorker
+	comments       *comment.CommentController
+	feed           *feed.FeedController
+	media          *media.LocalStore
+	micropub       *micropub.MicropubController
+	moderation     *moderation.ModerationController
 	userService    *user.UserService
+	guard          user.Guard
 	postController *post.PostController
+	postScheduler  *post.Scheduler
+	sitemap        *sitemap.SitemapController
+	syndication    *syndication.SyndicationController
+	taxonomy       *taxonomy.TaxonomyController
 	userController *user.UserController
+	webmention     *webmention.WebmentionController
+	webmentions    *webmention.Worker
+	wellKnown      *wellknown.WellKnownController
+	xmlrpc         *xmlrpc.XMLRPCController
 }
 
-func NewRouter(pool *pgxpool.Pool) *gin.Engine {
+func NewRouter(ctx context.Context, pool *pgxpool.Pool, cfg config.Config, keys *auth.KeySet) *gin.Engine {
 	r := gin.Default()
-	register(r, wire(pool))
+	s := wire(pool, cfg, keys)
+	register(r, s)
+	start(ctx, s)
 	return r
 }
 
-func wire(pool *pgxpool.Pool) *services {
+func wire(pool *pgxpool.Pool, cfg config.Config, keys *auth.KeySet) *services {
+	auth.UseKeySet(keys)
+
 	userRepository := user.NewPostgresUserRepository(pool)
-	userService := user.NewUserService(userRepository)
+	tokenRepository := user.NewPostgresTokenRepository(pool)
+	auth.UseRevocationStore(tokenRepository)
+	userService := user.NewUserService(userRepository, tokenRepository)
 	userController := user.NewUserController(userService)
 
+	taxonomyRepository := taxonomy.NewPostgresTaxonomyRepository(pool)
+	taxonomyService := taxonomy.NewTaxonomyService(taxonomyRepository)
+	taxonomyController := taxonomy.NewTaxonomyController(taxonomyService)
+
+	moderationRepository := moderation.NewPostgresModerationRepository(pool)
+	moderationService := moderation.NewModerationService(moderationRepository, moderation.Rules{
+		FirstTime:     cfg.ModerationFirstTime,
+		MaxLinks:      cfg.ModerationMaxLinks,
+		BannedWords:   cfg.ModerationBannedWords,
+		SpamThreshold: cfg.ModerationSpamThreshold,
+	})
+
 	postRepo := post.NewPostgresPostRepository(pool)
-	postService := post.NewPostService(postRepo, userService)
+	postService := post.NewPostService(postRepo, userService, taxonomyService, moderationService, cfg.SearchLanguage, cfg.ReactionTypes)
 	postController := post.NewPostController(postService)
+	moderationService.AddListener(postService)
+
+	feedRepository := feed.NewPostgresFeedRepository(pool)
+	feedService := feed.NewFeedService(feedRepository, postService, userService, cfg.FeedFanoutLimit)
+	postService.AddListener(feedService)
+
+	activityPubRepository := activitypub.NewPostgresActivityPubRepository(pool)
+	activityPubService := activitypub.NewActivityPubService(activityPubRepository, postService, userService, nil, cfg.SiteURL)
+	postService.AddListener(activityPubService)
+
+	webmentionRepository := webmention.NewPostgresWebmentionRepository(pool)
+	webmentionService := webmention.NewWebmentionService(webmentionRepository, postService, nil, cfg.SiteURL)
+	postService.AddListener(webmentionService)
+
+	commentRepository := comment.NewPostgresCommentRepository(pool)
+	commentService := comment.NewCommentService(commentRepository, postService, moderationService)
+
+	sitemapService := sitemap.NewSitemapService(sitemap.NewPostgresSitemapRepository(pool), cfg.SiteURL, cfg.RobotsFile, cfg.RobotsDisallow)
+	syndicationService := syndication.NewSyndicationService(syndication.NewPostgresSyndicationRepository(pool), postService, userService, cfg.SiteURL, cfg.SiteTitle, cfg.FeedSummary)
+	postService.AddListener(syndicationService)
+
+	mediaStore := media.NewLocalStore(cfg.MediaDir, cfg.SiteURL)
+	xmlrpcService := xmlrpc.NewXMLRPCService(userService, postService, mediaStore, cfg.SiteURL, cfg.SiteTitle)
 
 	return &services{
+		activityPub:    activitypub.NewActivityPubController(activityPubService),
+		apDelivery:     activitypub.NewDeliveryWorker(activityPubService, activityPubRepository, 15*time.Second),
+		comments:       comment.NewCommentController(commentService),
+		feed:           feed.NewFeedController(feedService),
+		media:          mediaStore,
+		micropub:       micropub.NewMicropubController(micropub.NewMicropubService(postService, mediaStore, cfg.SiteURL)),
+		moderation:     moderation.NewModerationController(moderationService),
 		userService:    userService,
+		guard:          user.NewGuard(userService),
 		postController: postController,
+		postScheduler:  post.NewScheduler(postService, 30*time.Second),
+		sitemap:        sitemap.NewSitemapController(sitemapService),
+		syndication:    syndication.NewSyndicationController(syndicationService),
+		taxonomy:       taxonomyController,
 		userController: userController,
+		webmention:     webmention.NewWebmentionController(webmentionService),
+		webmentions:    webmention.NewWorker(webmentionService, webmentionRepository, 30*time.Second),
+		wellKnown:      wellknown.NewWellKnownController(keys),
+		xmlrpc:         xmlrpc.NewXMLRPCController(xmlrpcService),
 	}
 }
 
 func register(r *gin.Engine, s *services) {
-	s.userController.RegisterRoutes(r)
-	s.postController.RegisterRoutes(r)
+	s.userController.RegisterRoutes(r, s.guard)
+	s.postController.RegisterRoutes(r, s.guard)
+	s.taxonomy.RegisterRoutes(r, s.guard)
+	s.comments.RegisterRoutes(r, s.guard)
+	s.feed.RegisterRoutes(r, s.guard)
+	s.moderation.RegisterRoutes(r, s.guard)
+	s.micropub.RegisterRoutes(r, s.guard)
+	s.webmention.RegisterRoutes(r, s.guard)
+	s.syndication.RegisterRoutes(r)
+	s.sitemap.RegisterRoutes(r)
+	s.wellKnown.RegisterRoutes(r)
+	s.xmlrpc.RegisterRoutes(r)
+	s.media.RegisterRoutes(r)
+	s.activityPub.RegisterRoutes(r)
+}
+
+func start(ctx context.Context, s *services) {
+	go s.postScheduler.Run(ctx)
+	go s.apDelivery.Run(ctx)
+	go s.webmentions.Run(ctx)
 }
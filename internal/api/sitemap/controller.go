@@ -0,0 +1,120 @@
+package sitemap
+
+import (
+	"bufio"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SitemapController struct {
+	service *SitemapService
+}
+
+func NewSitemapController(service *SitemapService) *SitemapController {
+	return &SitemapController{service: service}
+}
+
+func (c *SitemapController) RegisterRoutes(r *gin.Engine) {
+	r.GET("/sitemap.xml", c.Index)
+	r.GET("/sitemap/:page", c.Page)
+	r.GET("/robots.txt", c.Robots)
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type urlEntry struct {
+	XMLName xml.Name `xml:"url"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod"`
+}
+
+func (c *SitemapController) Index(ctx *gin.Context) {
+	pages, lastMod, apiErr := c.service.Pages(ctx.Request.Context())
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	index := sitemapIndex{}
+	for page := 1; page <= pages; page++ {
+		index.Sitemaps = append(index.Sitemaps, sitemapEntry{
+			Loc:     c.service.PageURL(page),
+			LastMod: lastMod.UTC().Format(time.RFC3339),
+		})
+	}
+
+	body, err := xml.MarshalIndent(index, "", "  ")
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+func (c *SitemapController) Page(ctx *gin.Context) {
+	page, err := strconv.Atoi(strings.TrimSuffix(ctx.Param("page"), ".xml"))
+	if err != nil || page < 1 {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "sitemap not found"})
+		return
+	}
+
+	pages, _, apiErr := c.service.Pages(ctx.Request.Context())
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+	if page > pages {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "sitemap not found"})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/xml; charset=utf-8")
+	ctx.Status(http.StatusOK)
+
+	w := bufio.NewWriter(ctx.Writer)
+	w.WriteString(xml.Header)
+	w.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+
+	encoder := xml.NewEncoder(w)
+	err = c.service.Stream(ctx.Request.Context(), page, func(loc string, lastMod time.Time) error {
+		return encoder.Encode(urlEntry{Loc: loc, LastMod: lastMod.UTC().Format(time.RFC3339)})
+	})
+	if err != nil {
+		log.Printf("sitemap: failed to stream page %d: %s", page, err)
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to build sitemap"})
+		}
+		// Otherwise part of the body was already flushed, so the truncated
+		// document is the only signal the client gets.
+		return
+	}
+
+	w.WriteString("\n</urlset>\n")
+	w.Flush()
+}
+
+func (c *SitemapController) Robots(ctx *gin.Context) {
+	robots, apiErr := c.service.Robots()
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.String(http.StatusOK, robots)
+}
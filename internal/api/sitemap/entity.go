@@ -0,0 +1,17 @@
+package sitemap
+
+import "time"
+
+type Kind int
+
+const (
+	KindPost Kind = iota + 1
+	KindAuthor
+	KindTag
+)
+
+type Entry struct {
+	Kind    Kind
+	Key     string
+	LastMod time.Time
+}
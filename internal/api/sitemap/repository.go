@@ -0,0 +1,81 @@
+package sitemap
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SitemapRepository interface {
+	Count(ctx context.Context) (int, time.Time, error)
+	Stream(ctx context.Context, limit, offset int, emit func(Entry) error) error
+}
+
+type PostgresSitemapRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresSitemapRepository(pool *pgxpool.Pool) *PostgresSitemapRepository {
+	return &PostgresSitemapRepository{pool: pool}
+}
+
+const entries = `
+		WITH visible AS (
+			SELECT id, slug, author_id, updated_at
+			FROM posts
+			WHERE status = 'published' AND moderation_status = 'approved'
+		), entries AS (
+			SELECT 1 AS kind, slug AS key, updated_at AS lastmod
+			FROM visible
+			UNION ALL
+			SELECT 2, author_id::text, max(updated_at)
+			FROM visible
+			GROUP BY author_id
+			UNION ALL
+			SELECT 3, t.slug, max(v.updated_at)
+			FROM visible v
+			JOIN post_tags pt ON pt.post_id = v.id
+			JOIN tags t ON t.id = pt.tag_id
+			GROUP BY t.slug
+		)
+`
+
+func (r *PostgresSitemapRepository) Count(ctx context.Context) (int, time.Time, error) {
+	query := entries + `
+		SELECT count(*), COALESCE(max(lastmod), to_timestamp(0))
+		FROM entries
+	`
+
+	var count int
+	var lastMod time.Time
+	err := r.pool.QueryRow(ctx, query).Scan(&count, &lastMod)
+	return count, lastMod, err
+}
+
+func (r *PostgresSitemapRepository) Stream(ctx context.Context, limit, offset int, emit func(Entry) error) error {
+	query := entries + `
+		SELECT kind, key, lastmod
+		FROM entries
+		ORDER BY kind, key
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(&entry.Kind, &entry.Key, &entry.LastMod); err != nil {
+			return err
+		}
+		if err := emit(entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
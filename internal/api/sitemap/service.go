@@ -0,0 +1,88 @@
+package sitemap
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+const urlsPerSitemap = 50000
+
+type SitemapService struct {
+	repository SitemapRepository
+	siteURL    string
+	robotsFile string
+	disallow   []string
+}
+
+func NewSitemapService(repo SitemapRepository, siteURL, robotsFile string, disallow []string) *SitemapService {
+	return &SitemapService{
+		repository: repo,
+		siteURL:    strings.TrimRight(siteURL, "/"),
+		robotsFile: robotsFile,
+		disallow:   disallow,
+	}
+}
+
+func (s *SitemapService) Pages(ctx context.Context) (int, time.Time, *errors.ApiError) {
+	count, lastMod, err := s.repository.Count(ctx)
+	if err != nil {
+		return 0, time.Time{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	pages := (count + urlsPerSitemap - 1) / urlsPerSitemap
+	return max(pages, 1), lastMod, nil
+}
+
+func (s *SitemapService) PageURL(page int) string {
+	return s.siteURL + "/sitemap/" + strconv.Itoa(page) + ".xml"
+}
+
+func (s *SitemapService) Stream(ctx context.Context, page int, emit func(loc string, lastMod time.Time) error) error {
+	return s.repository.Stream(ctx, urlsPerSitemap, (page-1)*urlsPerSitemap, func(entry Entry) error {
+		return emit(s.location(entry), entry.LastMod)
+	})
+}
+
+func (s *SitemapService) Robots() (string, *errors.ApiError) {
+	if s.robotsFile == "" {
+		return defaultRobots(s.siteURL, s.disallow), nil
+	}
+
+	content, err := os.ReadFile(s.robotsFile)
+	if err != nil {
+		return "", errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return string(content), nil
+}
+
+func (s *SitemapService) location(entry Entry) string {
+	key := url.PathEscape(entry.Key)
+	switch entry.Kind {
+	case KindAuthor:
+		return s.siteURL + "/authors/" + key
+	case KindTag:
+		return s.siteURL + "/tags/" + key
+	}
+	return s.siteURL + "/posts/" + key
+}
+
+func defaultRobots(siteURL string, disallow []string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(disallow) == 0 {
+		b.WriteString("Allow: /\n")
+	}
+	for _, path := range disallow {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	b.WriteString("\nSitemap: " + siteURL + "/sitemap.xml\n")
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package syndication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyndicationController struct {
+	service *SyndicationService
+}
+
+func NewSyndicationController(service *SyndicationService) *SyndicationController {
+	return &SyndicationController{service: service}
+}
+
+func (c *SyndicationController) RegisterRoutes(r *gin.Engine) {
+	r.GET("/feed.rss", c.serve(renderRSS, "application/rss+xml; charset=utf-8"))
+	r.GET("/feed.atom", c.serve(renderAtom, "application/atom+xml; charset=utf-8"))
+	r.GET("/feed.json", c.serve(renderJSON, "application/feed+json; charset=utf-8"))
+}
+
+func (c *SyndicationController) serve(render func(Feed) ([]byte, error), contentType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		feed, apiErr := c.service.Build(ctx.Request.Context(), ctx.Query("author"), ctx.Query("tag"), ctx.Request.URL.RequestURI())
+		if apiErr != nil {
+			ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+			return
+		}
+
+		body, err := render(feed)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		lastModified := feed.Updated.UTC().Truncate(time.Second)
+
+		ctx.Header("ETag", etag)
+		ctx.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+		ctx.Header("Cache-Control", "public, max-age=300")
+
+		if notModified(ctx.Request, etag, lastModified) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		ctx.Data(http.StatusOK, contentType, body)
+	}
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !lastModified.After(since)
+}
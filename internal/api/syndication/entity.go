@@ -0,0 +1,23 @@
+package syndication
+
+import "time"
+
+type Feed struct {
+	Title   string
+	Link    string
+	Self    string
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	Id        string
+	Link      string
+	Title     string
+	Author    string
+	Content   string
+	HTML      string
+	Summary   bool
+	Published time.Time
+	Updated   time.Time
+}
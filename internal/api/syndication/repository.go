@@ -0,0 +1,46 @@
+package syndication
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SyndicationRepository interface {
+	RemovedAt(ctx context.Context) (time.Time, error)
+	RecordRemoval(ctx context.Context) error
+}
+
+type PostgresSyndicationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresSyndicationRepository(pool *pgxpool.Pool) *PostgresSyndicationRepository {
+	return &PostgresSyndicationRepository{pool: pool}
+}
+
+func (r *PostgresSyndicationRepository) RemovedAt(ctx context.Context) (time.Time, error) {
+	query := `
+		SELECT removed_at
+		FROM syndication_state
+	`
+	var removedAt time.Time
+	err := r.pool.QueryRow(ctx, query).Scan(&removedAt)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return removedAt, err
+}
+
+func (r *PostgresSyndicationRepository) RecordRemoval(ctx context.Context) error {
+	query := `
+		INSERT INTO syndication_state (removed_at)
+		VALUES (now())
+		ON CONFLICT (id) DO UPDATE SET removed_at = excluded.removed_at
+	`
+	_, err := r.pool.Exec(ctx, query)
+	return err
+}
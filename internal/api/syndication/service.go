@@ -0,0 +1,128 @@
+package syndication
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+const feedSize = 20
+
+type SyndicationService struct {
+	repository  SyndicationRepository
+	postService *post.PostService
+	userService *user.UserService
+	siteURL     string
+	title       string
+	summary     bool
+}
+
+func NewSyndicationService(repo SyndicationRepository, postService *post.PostService, userService *user.UserService, siteURL, title string, summary bool) *SyndicationService {
+	return &SyndicationService{
+		repository:  repo,
+		postService: postService,
+		userService: userService,
+		siteURL:     strings.TrimRight(siteURL, "/"),
+		title:       title,
+		summary:     summary,
+	}
+}
+
+func (s *SyndicationService) Build(ctx context.Context, author, tag, self string) (Feed, *errors.ApiError) {
+	feed := Feed{
+		Title: s.title,
+		Link:  s.siteURL + "/",
+		Self:  s.siteURL + self,
+	}
+
+	var posts []post.Post
+	var apiErr *errors.ApiError
+	var authorName string
+
+	switch {
+	case author != "" && tag != "":
+		return Feed{}, errors.NewApiError(http.StatusBadRequest, "author and tag cannot be combined")
+	case author != "":
+		writer, findErr := s.userService.FindById(ctx, author)
+		if findErr != nil {
+			return Feed{}, errors.NewApiError(http.StatusNotFound, "author not found")
+		}
+		authorName = writer.Name
+		feed.Title += " — " + writer.Name
+		posts, _, apiErr = s.postService.FindAllByAuthor(ctx, author, !s.summary, feedSize, nil)
+	case tag != "":
+		feed.Title += " — #" + tag
+		posts, _, apiErr = s.postService.FindManyByCursor(ctx, feedSize, nil, post.PostFilter{Tags: []string{tag}, WithContent: !s.summary})
+	default:
+		posts, _, apiErr = s.postService.FindManyByCursor(ctx, feedSize, nil, post.PostFilter{WithContent: !s.summary})
+	}
+	if apiErr != nil {
+		return Feed{}, apiErr
+	}
+
+	for _, p := range posts {
+		item := Item{
+			Id:        "urn:uuid:" + p.ID,
+			Link:      s.siteURL + "/posts/" + p.Slug,
+			Title:     p.Title,
+			Author:    p.Author.Name,
+			Content:   p.Content,
+			HTML:      p.ContentHTML,
+			Published: p.CreatedAt,
+			Updated:   p.UpdatedAt,
+		}
+		if authorName != "" {
+			item.Author = authorName
+		}
+		if p.PublishedAt != nil {
+			item.Published = *p.PublishedAt
+		}
+		if s.summary {
+			item.Content, item.HTML = p.Excerpt, ""
+			item.Summary = true
+		}
+
+		feed.Updated = latest(feed.Updated, item.Published, item.Updated)
+		feed.Items = append(feed.Items, item)
+	}
+
+	removedAt, err := s.repository.RemovedAt(ctx)
+	if err != nil {
+		return Feed{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+	feed.Updated = latest(feed.Updated, removedAt)
+
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Unix(0, 0)
+	}
+
+	return feed, nil
+}
+
+func (s *SyndicationService) PostPublished(ctx context.Context, published post.Post) {}
+
+func (s *SyndicationService) PostUpdated(ctx context.Context, updated post.Post) {}
+
+// PostDeleted is also called when a post is unpublished. The post is no longer
+// in any feed to date it, so the removal time is kept instead.
+func (s *SyndicationService) PostDeleted(ctx context.Context, deleted post.Post) {
+	if err := s.repository.RecordRemoval(ctx); err != nil {
+		log.Printf("syndication: failed to record removal of post %s: %s", deleted.ID, err)
+	}
+}
+
+func latest(times ...time.Time) time.Time {
+	var max time.Time
+	for _, t := range times {
+		if t.After(max) {
+			max = t
+		}
+	}
+	return max
+}
@@ -0,0 +1,258 @@
+package taxonomy
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/user"
+)
+
+type TaxonomyController struct {
+	service *TaxonomyService
+}
+
+func NewTaxonomyController(service *TaxonomyService) *TaxonomyController {
+	return &TaxonomyController{service: service}
+}
+
+func (c *TaxonomyController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.POST("/tag", guard.Required, c.CreateTag)
+	r.GET("/tag", c.FindTag)
+	r.GET("/tag/findMany", c.FindTags)
+	r.GET("/tag/cloud", c.TagCloud)
+	r.POST("/tag/merge", guard.Required, c.MergeTags)
+	r.PATCH("/tag", guard.Required, c.UpdateTag)
+	r.DELETE("/tag", guard.Required, c.DeleteTag)
+
+	r.POST("/category", guard.Required, c.CreateCategory)
+	r.GET("/category", c.FindCategory)
+	r.GET("/category/tree", c.FindCategoryTree)
+	r.PATCH("/category", guard.Required, c.UpdateCategory)
+	r.DELETE("/category", guard.Required, c.DeleteCategory)
+}
+
+func (c *TaxonomyController) CreateTag(ctx *gin.Context) {
+	var dto CreateTagDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	tag, err := c.service.CreateTag(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "tag created",
+		"tag":     tag,
+	})
+}
+
+func (c *TaxonomyController) FindTag(ctx *gin.Context) {
+	slug := ctx.Query("slug")
+	if slug == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing slug"})
+		return
+	}
+
+	tag, err := c.service.FindTag(ctx.Request.Context(), slug)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tag)
+}
+
+func (c *TaxonomyController) FindTags(ctx *gin.Context) {
+	tags, err := c.service.FindTags(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "tags found",
+		"tags":    tags,
+	})
+}
+
+func (c *TaxonomyController) TagCloud(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return
+	}
+
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	cloud, apiErr := c.service.TagCloud(ctx.Request.Context(), limit)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "tag cloud found",
+		"tags":    cloud,
+	})
+}
+
+func (c *TaxonomyController) MergeTags(ctx *gin.Context) {
+	var dto MergeTagsDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	tag, err := c.service.MergeTags(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "tags merged",
+		"tag":     tag,
+	})
+}
+
+func (c *TaxonomyController) UpdateTag(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	var dto UpdateTagDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	tag, err := c.service.UpdateTag(ctx.Request.Context(), id, dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "tag updated",
+		"tag":     tag,
+	})
+}
+
+func (c *TaxonomyController) DeleteTag(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	if err := c.service.DeleteTag(ctx.Request.Context(), id); err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "tag deleted",
+	})
+}
+
+func (c *TaxonomyController) CreateCategory(ctx *gin.Context) {
+	var dto CreateCategoryDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	category, err := c.service.CreateCategory(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":  "category created",
+		"category": category,
+	})
+}
+
+func (c *TaxonomyController) FindCategory(ctx *gin.Context) {
+	slug := ctx.Query("slug")
+	if slug == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing slug"})
+		return
+	}
+
+	category, err := c.service.FindCategory(ctx.Request.Context(), slug)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, category)
+}
+
+func (c *TaxonomyController) FindCategoryTree(ctx *gin.Context) {
+	tree, err := c.service.FindCategoryTree(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    "categories found",
+		"categories": tree,
+	})
+}
+
+func (c *TaxonomyController) UpdateCategory(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	var dto UpdateCategoryDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	category, err := c.service.UpdateCategory(ctx.Request.Context(), id, dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "category updated",
+		"category": category,
+	})
+}
+
+func (c *TaxonomyController) DeleteCategory(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+		return
+	}
+
+	if err := c.service.DeleteCategory(ctx.Request.Context(), id); err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "category deleted",
+	})
+}
@@ -0,0 +1,25 @@
+package taxonomy
+
+type CreateTagDTO struct {
+	Name    string `json:"name" binding:"required"`
+	AliasOf string `json:"aliasOf"`
+}
+
+type UpdateTagDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type MergeTagsDTO struct {
+	Source string `json:"source" binding:"required"`
+	Target string `json:"target" binding:"required"`
+}
+
+type CreateCategoryDTO struct {
+	Name     string  `json:"name" binding:"required"`
+	ParentId *string `json:"parentId"`
+}
+
+type UpdateCategoryDTO struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,min=1"`
+	ParentId *string `json:"parentId,omitempty"`
+}
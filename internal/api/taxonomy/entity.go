@@ -0,0 +1,25 @@
+package taxonomy
+
+import "time"
+
+type Tag struct {
+	Id          string    `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	CanonicalId *string   `json:"canonicalId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type TagCount struct {
+	Tag
+	Count int `json:"count"`
+}
+
+type Category struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	ParentId  *string    `json:"parentId"`
+	Children  []Category `json:"children,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
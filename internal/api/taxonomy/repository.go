@@ -0,0 +1,386 @@
+package taxonomy
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TaxonomyRepository interface {
+	CreateTag(ctx context.Context, name, slug string, canonicalId *string) (Tag, error)
+	FindTagById(ctx context.Context, id string) (Tag, error)
+	FindTagBySlug(ctx context.Context, slug string) (Tag, error)
+	FindTagsBySlugs(ctx context.Context, slugs []string) ([]Tag, error)
+	FindTags(ctx context.Context) ([]Tag, error)
+	FindTagsByPosts(ctx context.Context, postIds []string) (map[string][]Tag, error)
+	UpdateTag(ctx context.Context, id, name, slug string) (Tag, error)
+	DeleteTag(ctx context.Context, id string) error
+	MergeTags(ctx context.Context, sourceId, targetId string) error
+	TagCloud(ctx context.Context, limit int) ([]TagCount, error)
+
+	CreateCategory(ctx context.Context, name, slug string, parentId *string) (Category, error)
+	FindCategoryById(ctx context.Context, id string) (Category, error)
+	FindCategoryBySlug(ctx context.Context, slug string) (Category, error)
+	FindCategories(ctx context.Context) ([]Category, error)
+	IsCategoryDescendant(ctx context.Context, id, ancestorId string) (bool, error)
+	UpdateCategory(ctx context.Context, id string, name, slug *string, parentId *string, detach bool) (Category, error)
+	DeleteCategory(ctx context.Context, id string) error
+}
+
+type PostgresTaxonomyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTaxonomyRepository(pool *pgxpool.Pool) *PostgresTaxonomyRepository {
+	return &PostgresTaxonomyRepository{pool: pool}
+}
+
+const (
+	tagColumns      = `id, name, slug, canonical_id, created_at`
+	categoryColumns = `id, name, slug, parent_id, created_at`
+)
+
+func (r *PostgresTaxonomyRepository) CreateTag(ctx context.Context, name, slug string, canonicalId *string) (Tag, error) {
+	query := `
+		INSERT INTO tags (name, slug, canonical_id)
+		VALUES ($1, $2, $3)
+		RETURNING ` + tagColumns + `
+	`
+
+	return scanTag(r.pool.QueryRow(ctx, query, name, slug, canonicalId))
+}
+
+func (r *PostgresTaxonomyRepository) FindTagById(ctx context.Context, id string) (Tag, error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE id = $1
+	`
+
+	return scanTag(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *PostgresTaxonomyRepository) FindTagBySlug(ctx context.Context, slug string) (Tag, error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE slug = $1
+	`
+
+	return scanTag(r.pool.QueryRow(ctx, query, slug))
+}
+
+func (r *PostgresTaxonomyRepository) FindTagsBySlugs(ctx context.Context, slugs []string) ([]Tag, error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE slug = ANY($1)
+	`
+
+	return collectTags(r.pool.Query(ctx, query, slugs))
+}
+
+func (r *PostgresTaxonomyRepository) FindTags(ctx context.Context) ([]Tag, error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		ORDER BY name
+	`
+
+	return collectTags(r.pool.Query(ctx, query))
+}
+
+func (r *PostgresTaxonomyRepository) FindTagsByPosts(ctx context.Context, postIds []string) (map[string][]Tag, error) {
+	query := `
+		SELECT pt.post_id, t.id, t.name, t.slug, t.canonical_id, t.created_at
+		FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.post_id = ANY($1)
+		ORDER BY t.name
+	`
+
+	rows, err := r.pool.Query(ctx, query, postIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := map[string][]Tag{}
+	for rows.Next() {
+		var postId string
+		var tag Tag
+		if err := rows.Scan(&postId, &tag.Id, &tag.Name, &tag.Slug, &tag.CanonicalId, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags[postId] = append(tags[postId], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *PostgresTaxonomyRepository) UpdateTag(ctx context.Context, id, name, slug string) (Tag, error) {
+	query := `
+		UPDATE tags
+		SET name = $2, slug = $3
+		WHERE id = $1
+		RETURNING ` + tagColumns + `
+	`
+
+	return scanTag(r.pool.QueryRow(ctx, query, id, name, slug))
+}
+
+func (r *PostgresTaxonomyRepository) DeleteTag(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM tags
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+func (r *PostgresTaxonomyRepository) MergeTags(ctx context.Context, sourceId, targetId string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO post_tags (post_id, tag_id)
+			SELECT post_id, $2 FROM post_tags WHERE tag_id = $1
+			ON CONFLICT DO NOTHING
+		`, sourceId, targetId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			DELETE FROM post_tags
+			WHERE tag_id = $1
+		`, sourceId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE tags
+			SET canonical_id = $2
+			WHERE id = $1 OR canonical_id = $1
+		`, sourceId, targetId)
+		return err
+	})
+}
+
+func (r *PostgresTaxonomyRepository) TagCloud(ctx context.Context, limit int) ([]TagCount, error) {
+	query := `
+		SELECT t.id, t.name, t.slug, t.canonical_id, t.created_at, count(p.id)
+		FROM tags t
+		JOIN post_tags pt ON pt.tag_id = t.id
+		JOIN posts p ON p.id = pt.post_id AND p.status = 'published'
+		WHERE t.canonical_id IS NULL
+		GROUP BY t.id
+		ORDER BY count(p.id) DESC, t.name
+		LIMIT $1
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cloud := []TagCount{}
+	for rows.Next() {
+		var entry TagCount
+		err := rows.Scan(
+			&entry.Id,
+			&entry.Name,
+			&entry.Slug,
+			&entry.CanonicalId,
+			&entry.CreatedAt,
+			&entry.Count,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cloud = append(cloud, entry)
+	}
+
+	return cloud, rows.Err()
+}
+
+func (r *PostgresTaxonomyRepository) CreateCategory(ctx context.Context, name, slug string, parentId *string) (Category, error) {
+	query := `
+		INSERT INTO categories (name, slug, parent_id)
+		VALUES ($1, $2, $3)
+		RETURNING ` + categoryColumns + `
+	`
+
+	return scanCategory(r.pool.QueryRow(ctx, query, name, slug, parentId))
+}
+
+func (r *PostgresTaxonomyRepository) FindCategoryById(ctx context.Context, id string) (Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		WHERE id = $1
+	`
+
+	return scanCategory(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *PostgresTaxonomyRepository) FindCategoryBySlug(ctx context.Context, slug string) (Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		WHERE slug = $1
+	`
+
+	return scanCategory(r.pool.QueryRow(ctx, query, slug))
+}
+
+func (r *PostgresTaxonomyRepository) FindCategories(ctx context.Context) ([]Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		ORDER BY name
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *PostgresTaxonomyRepository) IsCategoryDescendant(ctx context.Context, id, ancestorId string) (bool, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = $2
+			UNION ALL
+			SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT EXISTS (SELECT 1 FROM tree WHERE id = $1)
+	`
+	var descendant bool
+	err := r.pool.QueryRow(ctx, query, id, ancestorId).Scan(&descendant)
+	return descendant, err
+}
+
+func (r *PostgresTaxonomyRepository) UpdateCategory(ctx context.Context, id string, name, slug *string, parentId *string, detach bool) (Category, error) {
+	query := `
+		UPDATE categories
+		SET name = COALESCE($2, name),
+		    slug = COALESCE($3, slug),
+		    parent_id = CASE WHEN $5 THEN NULL ELSE COALESCE($4, parent_id) END
+		WHERE id = $1
+		RETURNING ` + categoryColumns + `
+	`
+
+	return scanCategory(r.pool.QueryRow(ctx, query, id, name, slug, parentId, detach))
+}
+
+func (r *PostgresTaxonomyRepository) DeleteCategory(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM categories
+		WHERE id = $1
+	`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// EnsureTags creates the missing tags within tx, so they are rolled back with
+// the write that uses them, and returns the canonical tag for each.
+func EnsureTags(ctx context.Context, tx pgx.Tx, tags []Tag) ([]Tag, error) {
+	resolved := []Tag{}
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tags (name, slug)
+			VALUES ($1, $2)
+			ON CONFLICT (slug) DO NOTHING
+		`, tag.Name, tag.Slug)
+		if err != nil {
+			return nil, err
+		}
+
+		canonical, err := scanTag(tx.QueryRow(ctx, `
+			SELECT c.id, c.name, c.slug, c.canonical_id, c.created_at
+			FROM tags t
+			JOIN tags c ON c.id = COALESCE(t.canonical_id, t.id)
+			WHERE t.slug = $1
+		`, tag.Slug))
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[canonical.Id] {
+			seen[canonical.Id] = true
+			resolved = append(resolved, canonical)
+		}
+	}
+
+	return resolved, nil
+}
+
+func scanTag(row pgx.Row) (Tag, error) {
+	var tag Tag
+
+	err := row.Scan(
+		&tag.Id,
+		&tag.Name,
+		&tag.Slug,
+		&tag.CanonicalId,
+		&tag.CreatedAt,
+	)
+
+	if err != nil {
+		return Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func collectTags(rows pgx.Rows, err error) ([]Tag, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func scanCategory(row pgx.Row) (Category, error) {
+	var category Category
+
+	err := row.Scan(
+		&category.Id,
+		&category.Name,
+		&category.Slug,
+		&category.ParentId,
+		&category.CreatedAt,
+	)
+
+	if err != nil {
+		return Category{}, err
+	}
+
+	return category, nil
+}
@@ -0,0 +1,376 @@
+package taxonomy
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/database"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+type TaxonomyService struct {
+	repository TaxonomyRepository
+}
+
+func NewTaxonomyService(repo TaxonomyRepository) *TaxonomyService {
+	return &TaxonomyService{repository: repo}
+}
+
+func (s *TaxonomyService) CreateTag(ctx context.Context, createTagDTO CreateTagDTO) (Tag, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return Tag{}, apiErr
+	}
+
+	slug := normalize(createTagDTO.Name)
+	if slug == "" {
+		return Tag{}, errors.NewApiError(http.StatusBadRequest, "invalid tag name")
+	}
+
+	var canonicalId *string
+	if createTagDTO.AliasOf != "" {
+		canonical, apiErr := s.FindTag(ctx, normalize(createTagDTO.AliasOf))
+		if apiErr != nil {
+			return Tag{}, apiErr
+		}
+		id := canonicalOf(canonical)
+		canonicalId = &id
+	}
+
+	tag, err := s.repository.CreateTag(ctx, createTagDTO.Name, slug, canonicalId)
+	if database.IsUniqueViolation(err) {
+		return Tag{}, errors.NewApiError(http.StatusConflict, "tag already exists")
+	}
+	if err != nil {
+		return Tag{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return tag, nil
+}
+
+func (s *TaxonomyService) FindTag(ctx context.Context, slug string) (Tag, *errors.ApiError) {
+	tag, err := s.repository.FindTagBySlug(ctx, slug)
+	if err != nil {
+		return Tag{}, errors.NewApiError(http.StatusNotFound, "tag not found")
+	}
+
+	return tag, nil
+}
+
+func (s *TaxonomyService) FindTags(ctx context.Context) ([]Tag, *errors.ApiError) {
+	tags, err := s.repository.FindTags(ctx)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return tags, nil
+}
+
+func (s *TaxonomyService) UpdateTag(ctx context.Context, id string, updateTagDTO UpdateTagDTO) (Tag, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return Tag{}, apiErr
+	}
+
+	slug := normalize(updateTagDTO.Name)
+	if slug == "" {
+		return Tag{}, errors.NewApiError(http.StatusBadRequest, "invalid tag name")
+	}
+
+	tag, err := s.repository.UpdateTag(ctx, id, updateTagDTO.Name, slug)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return Tag{}, errors.NewApiError(http.StatusNotFound, "tag not found")
+	}
+	if database.IsUniqueViolation(err) {
+		return Tag{}, errors.NewApiError(http.StatusConflict, "tag already exists")
+	}
+	if err != nil {
+		return Tag{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return tag, nil
+}
+
+func (s *TaxonomyService) DeleteTag(ctx context.Context, id string) *errors.ApiError {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return apiErr
+	}
+
+	if _, err := s.repository.FindTagById(ctx, id); err != nil {
+		return errors.NewApiError(http.StatusNotFound, "tag not found")
+	}
+
+	if err := s.repository.DeleteTag(ctx, id); err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func (s *TaxonomyService) MergeTags(ctx context.Context, mergeTagsDTO MergeTagsDTO) (Tag, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return Tag{}, apiErr
+	}
+
+	source, apiErr := s.FindTag(ctx, normalize(mergeTagsDTO.Source))
+	if apiErr != nil {
+		return Tag{}, apiErr
+	}
+
+	target, apiErr := s.FindTag(ctx, normalize(mergeTagsDTO.Target))
+	if apiErr != nil {
+		return Tag{}, apiErr
+	}
+
+	targetId := canonicalOf(target)
+	if source.Id == targetId {
+		return Tag{}, errors.NewApiError(http.StatusBadRequest, "cannot merge a tag into itself")
+	}
+
+	if err := s.repository.MergeTags(ctx, source.Id, targetId); err != nil {
+		return Tag{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	merged, err := s.repository.FindTagById(ctx, targetId)
+	if err != nil {
+		return Tag{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return merged, nil
+}
+
+func (s *TaxonomyService) TagCloud(ctx context.Context, limit int) ([]TagCount, *errors.ApiError) {
+	cloud, err := s.repository.TagCloud(ctx, limit)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return cloud, nil
+}
+
+// ParseTags validates tag names and drops duplicates. The tags are only
+// created, by EnsureTags, in the transaction of the post that uses them.
+func (s *TaxonomyService) ParseTags(names []string) ([]Tag, *errors.ApiError) {
+	parsed := []Tag{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		slug := normalize(name)
+		if slug == "" {
+			return nil, errors.NewApiError(http.StatusBadRequest, "invalid tag name")
+		}
+
+		if !seen[slug] {
+			seen[slug] = true
+			parsed = append(parsed, Tag{Name: name, Slug: slug})
+		}
+	}
+
+	return parsed, nil
+}
+
+func (s *TaxonomyService) ResolveTagIds(ctx context.Context, slugs []string) ([]string, int, *errors.ApiError) {
+	normalized := make([]string, 0, len(slugs))
+	for _, slug := range slugs {
+		if n := normalize(slug); n != "" {
+			normalized = append(normalized, n)
+		}
+	}
+
+	tags, err := s.repository.FindTagsBySlugs(ctx, normalized)
+	if err != nil {
+		return nil, 0, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	found := map[string]bool{}
+	ids := []string{}
+	for _, tag := range tags {
+		found[tag.Slug] = true
+		id := canonicalOf(tag)
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	unknown := 0
+	for _, slug := range normalized {
+		if !found[slug] {
+			unknown++
+		}
+	}
+
+	return ids, unknown, nil
+}
+
+func (s *TaxonomyService) TagsByPosts(ctx context.Context, postIds []string) (map[string][]Tag, *errors.ApiError) {
+	if len(postIds) == 0 {
+		return map[string][]Tag{}, nil
+	}
+
+	tags, err := s.repository.FindTagsByPosts(ctx, postIds)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return tags, nil
+}
+
+func (s *TaxonomyService) CreateCategory(ctx context.Context, createCategoryDTO CreateCategoryDTO) (Category, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return Category{}, apiErr
+	}
+
+	slug := normalize(createCategoryDTO.Name)
+	if slug == "" {
+		return Category{}, errors.NewApiError(http.StatusBadRequest, "invalid category name")
+	}
+
+	if createCategoryDTO.ParentId != nil {
+		if _, apiErr := s.FindCategoryById(ctx, *createCategoryDTO.ParentId); apiErr != nil {
+			return Category{}, errors.NewApiError(http.StatusBadRequest, "parent category does not exist")
+		}
+	}
+
+	category, err := s.repository.CreateCategory(ctx, createCategoryDTO.Name, slug, createCategoryDTO.ParentId)
+	if database.IsUniqueViolation(err) {
+		return Category{}, errors.NewApiError(http.StatusConflict, "category already exists")
+	}
+	if err != nil {
+		return Category{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return category, nil
+}
+
+func (s *TaxonomyService) FindCategoryById(ctx context.Context, id string) (Category, *errors.ApiError) {
+	category, err := s.repository.FindCategoryById(ctx, id)
+	if err != nil {
+		return Category{}, errors.NewApiError(http.StatusNotFound, "category not found")
+	}
+
+	return category, nil
+}
+
+func (s *TaxonomyService) FindCategory(ctx context.Context, slug string) (Category, *errors.ApiError) {
+	category, err := s.repository.FindCategoryBySlug(ctx, slug)
+	if err != nil {
+		return Category{}, errors.NewApiError(http.StatusNotFound, "category not found")
+	}
+
+	return category, nil
+}
+
+func (s *TaxonomyService) FindCategoryTree(ctx context.Context) ([]Category, *errors.ApiError) {
+	categories, err := s.repository.FindCategories(ctx)
+	if err != nil {
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return buildTree(categories), nil
+}
+
+func (s *TaxonomyService) UpdateCategory(ctx context.Context, id string, updateCategoryDTO UpdateCategoryDTO) (Category, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return Category{}, apiErr
+	}
+
+	if _, apiErr := s.FindCategoryById(ctx, id); apiErr != nil {
+		return Category{}, apiErr
+	}
+
+	var slug *string
+	if updateCategoryDTO.Name != nil {
+		normalized := normalize(*updateCategoryDTO.Name)
+		if normalized == "" {
+			return Category{}, errors.NewApiError(http.StatusBadRequest, "invalid category name")
+		}
+		slug = &normalized
+	}
+
+	detach := updateCategoryDTO.ParentId != nil && *updateCategoryDTO.ParentId == ""
+	parentId := updateCategoryDTO.ParentId
+	if detach {
+		parentId = nil
+	}
+
+	if parentId != nil {
+		if _, apiErr := s.FindCategoryById(ctx, *parentId); apiErr != nil {
+			return Category{}, errors.NewApiError(http.StatusBadRequest, "parent category does not exist")
+		}
+
+		cycle, err := s.repository.IsCategoryDescendant(ctx, *parentId, id)
+		if err != nil {
+			return Category{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+		}
+		if cycle {
+			return Category{}, errors.NewApiError(http.StatusBadRequest, "category cannot be moved under itself")
+		}
+	}
+
+	category, err := s.repository.UpdateCategory(ctx, id, updateCategoryDTO.Name, slug, parentId, detach)
+	if database.IsUniqueViolation(err) {
+		return Category{}, errors.NewApiError(http.StatusConflict, "category already exists")
+	}
+	if err != nil {
+		return Category{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return category, nil
+}
+
+func (s *TaxonomyService) DeleteCategory(ctx context.Context, id string) *errors.ApiError {
+	if _, apiErr := user.Authorize(ctx, user.User.CanManageTaxonomy); apiErr != nil {
+		return apiErr
+	}
+
+	if _, apiErr := s.FindCategoryById(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	err := s.repository.DeleteCategory(ctx, id)
+	if database.IsForeignKeyViolation(err) {
+		return errors.NewApiError(http.StatusConflict, "category has subcategories")
+	}
+	if err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func canonicalOf(tag Tag) string {
+	if tag.CanonicalId != nil {
+		return *tag.CanonicalId
+	}
+	return tag.Id
+}
+
+func buildTree(categories []Category) []Category {
+	children := map[string][]Category{}
+	var roots []Category
+
+	for _, category := range categories {
+		if category.ParentId == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentId] = append(children[*category.ParentId], category)
+	}
+
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].Id])
+		}
+		return nodes
+	}
+
+	tree := attach(roots)
+	if tree == nil {
+		tree = []Category{}
+	}
+	return tree
+}
@@ -0,0 +1,11 @@
+package taxonomy
+
+import (
+	"strings"
+
+	"github.com/gosimple/slug"
+)
+
+func normalize(name string) string {
+	return strings.Trim(slug.Make(strings.TrimSpace(name)), "-")
+}
@@ -14,12 +14,15 @@ func NewUserController(service *UserService) *UserController {
 	return &UserController{service: service}
 }
 
-func (c *UserController) RegisterRoutes(r *gin.Engine) {
+func (c *UserController) RegisterRoutes(r *gin.Engine, guard Guard) {
 	r.POST("/user", c.Create)
 	r.POST("/user/login", c.Login)
+	r.POST("/user/refresh", c.Refresh)
+	r.POST("/user/logout", guard.Scoped, c.Logout)
+	r.POST("/user/token", guard.Required, c.CreateToken)
 	r.GET("/user/decodeToken", c.DecodeToken)
-	r.PATCH("/user", c.Update)
-	r.DELETE("/user", c.Delete)
+	r.PATCH("/user", guard.Required, c.Update)
+	r.DELETE("/user", guard.Required, c.Delete)
 }
 
 func (c *UserController) Create(ctx *gin.Context) {
@@ -29,16 +32,18 @@ func (c *UserController) Create(ctx *gin.Context) {
 		return
 	}
 
-	user, token, err := c.service.Create(ctx.Request.Context(), dto)
+	user, tokens, err := c.service.Create(ctx.Request.Context(), dto)
 	if err != nil {
 		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"message": "user created",
-		"user":    user,
-		"token":   token,
+		"message":      "user created",
+		"user":         user,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresAt":    tokens.ExpiresAt,
 	})
 }
 
@@ -49,16 +54,82 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, token, err := c.service.Login(ctx.Request.Context(), dto)
+	user, tokens, err := c.service.Login(ctx.Request.Context(), dto)
 	if err != nil {
 		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"message": "user logged in",
-		"user":    user,
-		"token":   token,
+		"message":      "user logged in",
+		"user":         user,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresAt":    tokens.ExpiresAt,
+	})
+}
+
+func (c *UserController) Refresh(ctx *gin.Context) {
+	var dto RefreshTokenDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	tokens, err := c.service.Refresh(ctx.Request.Context(), dto.RefreshToken)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":      "token refreshed",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresAt":    tokens.ExpiresAt,
+	})
+}
+
+func (c *UserController) Logout(ctx *gin.Context) {
+	var dto LogoutDTO
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&dto); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+			return
+		}
+	}
+
+	claims, _ := TokenClaims(ctx)
+
+	err := c.service.Logout(ctx.Request.Context(), claims, dto.RefreshToken)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "user logged out",
+	})
+}
+
+func (c *UserController) CreateToken(ctx *gin.Context) {
+	var dto CreateTokenDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	token, claims, err := c.service.CreateToken(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.Code, gin.H{"message": err.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":   "token created",
+		"token":     token,
+		"scope":     claims.Scope,
+		"expiresAt": claims.ExpiresAt.Time,
 	})
 }
 
@@ -79,11 +150,8 @@ func (c *UserController) DecodeToken(ctx *gin.Context) {
 }
 
 func (c *UserController) Update(ctx *gin.Context) {
-	id := ctx.Query("id")
-	if id == "" {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
-		return
-	}
+	current, _ := CurrentUser(ctx)
+	id := ctx.DefaultQuery("id", current.Id)
 
 	var dto UpdateUserDTO
 	if err := ctx.ShouldBindJSON(&dto); err != nil {
@@ -104,11 +172,8 @@ func (c *UserController) Update(ctx *gin.Context) {
 }
 
 func (c *UserController) Delete(ctx *gin.Context) {
-	id := ctx.Query("id")
-	if id == "" {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
-		return
-	}
+	current, _ := CurrentUser(ctx)
+	id := ctx.DefaultQuery("id", current.Id)
 
 	err := c.service.Delete(ctx.Request.Context(), id)
 	if err != nil {
@@ -4,15 +4,29 @@ type CreateUserDTO struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Role     Role   `json:"-"`
 }
 
 type UpdateUserDTO struct {
 	Name     *string `json:"name,omitempty" binding:"omitempty"`
 	Email    *string `json:"email,omitempty" binding:"omitempty,email"`
 	Password *string `json:"password,omitempty" binding:"omitempty"`
+	Role     *Role   `json:"role,omitempty" binding:"omitempty,oneof=reader author editor admin"`
 }
 
 type LoginUserDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
-}
\ No newline at end of file
+}
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutDTO struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type CreateTokenDTO struct {
+	Scope string `json:"scope" binding:"required"`
+}
@@ -1,8 +1,9 @@
 package user
 
 type User struct {
-	Id string `json:"id"`
-	Name string `json:"name"`
-	Email string `json:"email"`
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
 	Password string `json:"password"`
-}
\ No newline at end of file
+	Role     Role   `json:"role"`
+}
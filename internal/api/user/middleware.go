@@ -0,0 +1,108 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/shared/auth"
+)
+
+type contextKey struct{}
+
+const (
+	currentUserKey = "currentUser"
+	tokenClaimsKey = "tokenClaims"
+)
+
+func NewContext(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+func FromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
+
+func CurrentUser(ctx *gin.Context) (User, bool) {
+	value, ok := ctx.Get(currentUserKey)
+	if !ok {
+		return User{}, false
+	}
+	user, ok := value.(User)
+	return user, ok
+}
+
+func TokenClaims(ctx *gin.Context) (*auth.Claims, bool) {
+	value, ok := ctx.Get(tokenClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*auth.Claims)
+	return claims, ok
+}
+
+// Guard authenticates bearer tokens. Required and Optional only accept
+// unscoped tokens; routes that check scopes themselves use Scoped.
+type Guard struct {
+	Required gin.HandlerFunc
+	Optional gin.HandlerFunc
+	Scoped   gin.HandlerFunc
+}
+
+func NewGuard(service *UserService) Guard {
+	return Guard{
+		Required: authenticate(service, true, false),
+		Optional: authenticate(service, false, false),
+		Scoped:   authenticate(service, true, true),
+	}
+}
+
+func authenticate(service *UserService, required, scoped bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		if header == "" && !required {
+			ctx.Next()
+			return
+		}
+
+		token, ok := bearerToken(header)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "missing token"})
+			return
+		}
+
+		claims, err := auth.ParseJWT(ctx.Request.Context(), token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token"})
+			return
+		}
+
+		if claims.Scope != "" && !scoped {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "token scope not allowed"})
+			return
+		}
+
+		user, apiErr := service.FindById(ctx.Request.Context(), claims.Subject)
+		if apiErr != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": apiErr.Message})
+			return
+		}
+
+		ctx.Set(currentUserKey, user)
+		ctx.Set(tokenClaimsKey, claims)
+		ctx.Request = ctx.Request.WithContext(NewContext(ctx.Request.Context(), user))
+		ctx.Next()
+	}
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+func (u User) CanManageUser(id string) bool {
+	return u.Role == RoleAdmin || u.Id == id
+}
+
+func (u User) CanAssignRoles() bool {
+	return u.Role == RoleAdmin
+}
+
+func (u User) CanWritePosts() bool {
+	switch u.Role {
+	case RoleAuthor, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (u User) CanManagePost(authorId string) bool {
+	switch u.Role {
+	case RoleEditor, RoleAdmin:
+		return true
+	case RoleAuthor:
+		return u.Id == authorId
+	}
+	return false
+}
+
+func Authorize(ctx context.Context, allowed func(User) bool) (User, *errors.ApiError) {
+	actor, ok := FromContext(ctx)
+	if !ok {
+		return User{}, errors.NewApiError(http.StatusUnauthorized, "unauthenticated")
+	}
+
+	if !allowed(actor) {
+		return User{}, errors.NewApiError(http.StatusForbidden, "forbidden")
+	}
+
+	return actor, nil
+}
+
+func (u User) CanManageTaxonomy() bool {
+	return u.Role == RoleEditor || u.Role == RoleAdmin
+}
+
+func (u User) CanComment() bool {
+	return u.Role.Valid()
+}
+
+func (u User) CanEditComment(authorId string) bool {
+	return u.Id == authorId
+}
+
+func (u User) CanDeleteComment(authorId, postAuthorId string) bool {
+	return u.Id == authorId || u.CanManagePost(postAuthorId)
+}
+
+func (u User) CanModerate() bool {
+	return u.Role == RoleEditor || u.Role == RoleAdmin
+}
+
+func (u User) CanReact() bool {
+	return u.Role.Valid()
+}
+
+func (u User) CanFollow() bool {
+	return u.Role.Valid()
+}
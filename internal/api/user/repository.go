@@ -24,17 +24,18 @@ func NewPostgresUserRepository(poll *pgxpool.Pool) *PostgresUserRepository {
 
 func (r *PostgresUserRepository) Create(ctx context.Context, createUserDTO CreateUserDTO) (User, error) {
 	query := `
-		INSERT INTO users (name, email, password)
-		VALUES ($1, $2, $3)
-		RETURNING id, name, email, password
+		INSERT INTO users (name, email, password, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, email, password, role
 	`
 	var user User
 
-	err := r.pool.QueryRow(ctx, query, createUserDTO.Name, createUserDTO.Email, createUserDTO.Password).Scan(
+	err := r.pool.QueryRow(ctx, query, createUserDTO.Name, createUserDTO.Email, createUserDTO.Password, createUserDTO.Role).Scan(
 		&user.Id,
 		&user.Name,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 	)
 
 	if err != nil {
@@ -46,7 +47,7 @@ func (r *PostgresUserRepository) Create(ctx context.Context, createUserDTO Creat
 
 func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string) (User, error) {
 	query := `
-		SELECT id, name, email, password
+		SELECT id, name, email, password, role
 		FROM users
 		WHERE email = $1
 	`
@@ -58,6 +59,7 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string)
 		&user.Name,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 	)
 
 	if err != nil {
@@ -69,7 +71,7 @@ func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string)
 
 func (r *PostgresUserRepository) FindById(ctx context.Context, id string) (User, error) {
 	query := `
-		SELECT id, name, email
+		SELECT id, name, email, role
 		FROM users
 		WHERE id = $1
 	`
@@ -80,6 +82,7 @@ func (r *PostgresUserRepository) FindById(ctx context.Context, id string) (User,
 		&user.Id,
 		&user.Name,
 		&user.Email,
+		&user.Role,
 	)
 
 	if err != nil {
@@ -94,18 +97,20 @@ func (r *PostgresUserRepository) Update(ctx context.Context, id string, updateUs
 		UPDATE users
 		SET name = COALESCE($2, name),
 		    email = COALESCE($3, email),
-		    password = COALESCE($4, password)
+		    password = COALESCE($4, password),
+		    role = COALESCE($5, role)
 		WHERE id = $1
-		RETURNING id, name, email, password
+		RETURNING id, name, email, password, role
 	`
 
 	var user User
 
-	err := r.pool.QueryRow(ctx, query, id, updateUserDTO.Name, updateUserDTO.Email, updateUserDTO.Password).Scan(
+	err := r.pool.QueryRow(ctx, query, id, updateUserDTO.Name, updateUserDTO.Email, updateUserDTO.Password, updateUserDTO.Role).Scan(
 		&user.Id,
 		&user.Name,
 		&user.Email,
 		&user.Password,
+		&user.Role,
 	)
 
 	if err != nil {
@@ -0,0 +1,18 @@
+package user
+
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleAuthor Role = "author"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleReader, RoleAuthor, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
@@ -2,7 +2,11 @@ package user
 
 import (
 	"context"
+	stderrors "errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/joaopdias/blog-server/internal/shared/auth"
 	"github.com/joaopdias/blog-server/internal/shared/errors"
@@ -10,53 +14,166 @@ import (
 
 type UserService struct {
 	repository UserRepository
+	tokens     TokenRepository
 }
 
-func NewUserService(repo UserRepository) *UserService {
-	return &UserService{repository: repo}
+func NewUserService(repo UserRepository, tokens TokenRepository) *UserService {
+	return &UserService{repository: repo, tokens: tokens}
 }
 
-func (s *UserService) Create(ctx context.Context, createUserDTO CreateUserDTO) (User, string, *errors.ApiError) {
+func (s *UserService) Create(ctx context.Context, createUserDTO CreateUserDTO) (User, TokenPair, *errors.ApiError) {
 	_, err := s.repository.FindByEmail(ctx, createUserDTO.Email)
 	if err == nil {
-		return User{}, "", errors.NewApiError(http.StatusConflict, "user already exists")
+		return User{}, TokenPair{}, errors.NewApiError(http.StatusConflict, "user already exists")
 	}
 
 	createUserDTO.Password = auth.HashPassword(createUserDTO.Password)
+	createUserDTO.Role = RoleAuthor
 
 	user, err := s.repository.Create(ctx, createUserDTO)
 	if err != nil {
-		return User{}, "", errors.NewApiError(http.StatusInternalServerError, err.Error())
+		return User{}, TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
-	token, err := auth.GenerateJWT(user.Id)
+	tokens, apiErr := s.issueTokens(ctx, user.Id)
+	if apiErr != nil {
+		return User{}, TokenPair{}, apiErr
+	}
+
+	user.Password = ""
+
+	return user, tokens, nil
+}
+
+func (s *UserService) Login(ctx context.Context, loginUserDTO LoginUserDTO) (User, TokenPair, *errors.ApiError) {
+	user, apiErr := s.Authenticate(ctx, loginUserDTO.Email, loginUserDTO.Password)
+	if apiErr != nil {
+		return User{}, TokenPair{}, apiErr
+	}
+
+	tokens, apiErr := s.issueTokens(ctx, user.Id)
+	if apiErr != nil {
+		return User{}, TokenPair{}, apiErr
+	}
+
+	return user, tokens, nil
+}
+
+// Authenticate checks a user's credentials without issuing tokens, for
+// protocols that send them with every request.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (User, *errors.ApiError) {
+	user, err := s.repository.FindByEmail(ctx, email)
 	if err != nil {
-		return User{}, "", errors.NewApiError(http.StatusInternalServerError, err.Error())
+		return User{}, errors.NewApiError(http.StatusNotFound, "user not found")
+	}
+
+	if !auth.CheckPasswordHash(password, user.Password) {
+		return User{}, errors.NewApiError(http.StatusUnauthorized, "wrong password")
 	}
 
 	user.Password = ""
 
-	return user, token, nil
+	return user, nil
 }
 
-func (s *UserService) Login(ctx context.Context, loginUserDTO LoginUserDTO) (User, string, *errors.ApiError) {
-	user, err := s.repository.FindByEmail(ctx, loginUserDTO.Email)
+func (s *UserService) Refresh(ctx context.Context, refreshToken string) (TokenPair, *errors.ApiError) {
+	next, nextHash, err := auth.GenerateRefreshToken()
 	if err != nil {
-		return User{}, "", errors.NewApiError(http.StatusNotFound, "user not found")
+		return TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
-	if !auth.CheckPasswordHash(loginUserDTO.Password, user.Password) {
-		return User{}, "", errors.NewApiError(http.StatusUnauthorized, "wrong password")
+	rotated, err := s.tokens.RotateRefreshToken(ctx, auth.HashToken(refreshToken), RefreshToken{
+		TokenHash: nextHash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	switch {
+	case stderrors.Is(err, ErrRefreshTokenReused):
+		return TokenPair{}, errors.NewApiError(http.StatusUnauthorized, "refresh token reused")
+	case stderrors.Is(err, ErrRefreshTokenNotFound), stderrors.Is(err, ErrRefreshTokenExpired):
+		return TokenPair{}, errors.NewApiError(http.StatusUnauthorized, "invalid refresh token")
+	case err != nil:
+		return TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
-	token, err := auth.GenerateJWT(user.Id)
+	access, claims, err := auth.GenerateJWT(rotated.UserId)
 	if err != nil {
-		return User{}, "", errors.NewApiError(http.StatusInternalServerError, err.Error())
+		return TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
 	}
 
-	user.Password = ""
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: next,
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (s *UserService) Logout(ctx context.Context, claims *auth.Claims, refreshToken string) *errors.ApiError {
+	if refreshToken != "" {
+		err := s.tokens.RevokeRefreshTokenFamily(ctx, claims.Subject, auth.HashToken(refreshToken))
+		if err != nil {
+			return errors.NewApiError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	err := s.tokens.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
 
-	return user, token, nil
+// CreateToken issues a scoped access token for the caller, such as one to
+// paste into a Micropub client. It is revoked through logout like any other.
+func (s *UserService) CreateToken(ctx context.Context, createTokenDTO CreateTokenDTO) (string, *auth.Claims, *errors.ApiError) {
+	actor, apiErr := Authorize(ctx, func(u User) bool { return u.Role.Valid() })
+	if apiErr != nil {
+		return "", nil, apiErr
+	}
+
+	scopes := strings.Fields(createTokenDTO.Scope)
+	if len(scopes) == 0 {
+		return "", nil, errors.NewApiError(http.StatusBadRequest, "missing scope")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			return "", nil, errors.NewApiError(http.StatusBadRequest, "unknown scope "+scope)
+		}
+	}
+
+	token, claims, err := auth.GenerateScopedJWT(actor.Id, strings.Join(scopes, " "))
+	if err != nil {
+		return "", nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return token, claims, nil
+}
+
+func (s *UserService) issueTokens(ctx context.Context, userId string) (TokenPair, *errors.ApiError) {
+	access, claims, err := auth.GenerateJWT(userId)
+	if err != nil {
+		return TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	refresh, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	_, err = s.tokens.CreateRefreshToken(ctx, RefreshToken{
+		UserId:    userId,
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if err != nil {
+		return TokenPair{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}, nil
 }
 
 func (s *UserService) FindById(ctx context.Context, id string) (User, *errors.ApiError) {
@@ -68,15 +185,24 @@ func (s *UserService) FindById(ctx context.Context, id string) (User, *errors.Ap
 }
 
 func (s *UserService) DecodeToken(ctx context.Context, token string) (User, *errors.ApiError) {
-	id, err := auth.ParseJWT(token)
+	claims, err := auth.ParseJWT(ctx, token)
 	if err != nil {
 		return User{}, errors.NewApiError(http.StatusUnauthorized, "invalid token")
 	}
 
-	return s.FindById(ctx, id)
+	return s.FindById(ctx, claims.Subject)
 }
 
 func (s *UserService) Update(ctx context.Context, id string, updateUserDTO UpdateUserDTO) (User, *errors.ApiError) {
+	actor, apiErr := Authorize(ctx, func(u User) bool { return u.CanManageUser(id) })
+	if apiErr != nil {
+		return User{}, apiErr
+	}
+
+	if updateUserDTO.Role != nil && !actor.CanAssignRoles() {
+		return User{}, errors.NewApiError(http.StatusForbidden, "forbidden")
+	}
+
 	u, apiErr := s.FindById(ctx, id)
 	if apiErr != nil {
 		return u, apiErr
@@ -97,7 +223,12 @@ func (s *UserService) Update(ctx context.Context, id string, updateUserDTO Updat
 }
 
 func (s *UserService) Delete(ctx context.Context, id string) *errors.ApiError {
-	_, apiErr := s.FindById(ctx, id)
+	_, apiErr := Authorize(ctx, func(u User) bool { return u.CanManageUser(id) })
+	if apiErr != nil {
+		return apiErr
+	}
+
+	_, apiErr = s.FindById(ctx, id)
 	if apiErr != nil {
 		return apiErr
 	}
@@ -109,4 +240,3 @@ func (s *UserService) Delete(ctx context.Context, id string) *errors.ApiError {
 
 	return nil
 }
-
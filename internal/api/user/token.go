@@ -0,0 +1,19 @@
+package user
+
+import "time"
+
+type RefreshToken struct {
+	Id        string
+	UserId    string
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+type TokenPair struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRefreshTokenNotFound = stderrors.New("refresh token not found")
+	ErrRefreshTokenExpired  = stderrors.New("refresh token expired")
+	ErrRefreshTokenReused   = stderrors.New("refresh token reused")
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token RefreshToken) (RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, tokenHash string, next RefreshToken) (RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, userId, tokenHash string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type PostgresTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTokenRepository(pool *pgxpool.Pool) *PostgresTokenRepository {
+	return &PostgresTokenRepository{pool: pool}
+}
+
+func (r *PostgresTokenRepository) CreateRefreshToken(ctx context.Context, token RefreshToken) (RefreshToken, error) {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, COALESCE($2::uuid, gen_random_uuid()), $3, $4)
+		RETURNING id, user_id, family_id, token_hash, expires_at, revoked_at, created_at
+	`
+
+	var familyId *string
+	if token.FamilyId != "" {
+		familyId = &token.FamilyId
+	}
+
+	return scanRefreshToken(r.pool.QueryRow(ctx, query, token.UserId, familyId, token.TokenHash, token.ExpiresAt))
+}
+
+func (r *PostgresTokenRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next RefreshToken) (RefreshToken, error) {
+	var rotated RefreshToken
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		current, err := scanRefreshToken(tx.QueryRow(ctx, `
+			SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, created_at
+			FROM refresh_tokens
+			WHERE token_hash = $1
+			FOR UPDATE
+		`, tokenHash))
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return ErrRefreshTokenNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			return ErrRefreshTokenReused
+		}
+
+		if time.Now().After(current.ExpiresAt) {
+			return ErrRefreshTokenExpired
+		}
+
+		rotated, err = scanRefreshToken(tx.QueryRow(ctx, `
+			INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, user_id, family_id, token_hash, expires_at, revoked_at, created_at
+		`, current.UserId, current.FamilyId, next.TokenHash, next.ExpiresAt))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE refresh_tokens
+			SET revoked_at = now(), replaced_by = $2
+			WHERE id = $1
+		`, current.Id, rotated.Id)
+		return err
+	})
+
+	if stderrors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := r.revokeFamilyByHash(ctx, tokenHash); revokeErr != nil {
+			return RefreshToken{}, revokeErr
+		}
+	}
+
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	return rotated, nil
+}
+
+func (r *PostgresTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, userId, tokenHash string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE revoked_at IS NULL
+		  AND family_id = (
+			SELECT family_id FROM refresh_tokens
+			WHERE token_hash = $2 AND user_id = $1
+		  )
+	`
+	_, err := r.pool.Exec(ctx, query, userId, tokenHash)
+	return err
+}
+
+func (r *PostgresTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `
+		WITH purged AS (
+			DELETE FROM revoked_tokens WHERE expires_at < now()
+		)
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := r.pool.Exec(ctx, query, jti, expiresAt)
+	return err
+}
+
+func (r *PostgresTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+	`
+	var revoked bool
+	err := r.pool.QueryRow(ctx, query, jti).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *PostgresTokenRepository) revokeFamilyByHash(ctx context.Context, tokenHash string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE revoked_at IS NULL
+		  AND family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)
+	`
+	_, err := r.pool.Exec(ctx, query, tokenHash)
+	return err
+}
+
+func scanRefreshToken(row pgx.Row) (RefreshToken, error) {
+	var token RefreshToken
+
+	err := row.Scan(
+		&token.Id,
+		&token.UserId,
+		&token.FamilyId,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	return token, nil
+}
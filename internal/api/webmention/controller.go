@@ -0,0 +1,141 @@
+package webmention
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type WebmentionController struct {
+	service *WebmentionService
+}
+
+func NewWebmentionController(service *WebmentionService) *WebmentionController {
+	return &WebmentionController{service: service}
+}
+
+func (c *WebmentionController) RegisterRoutes(r *gin.Engine, guard user.Guard) {
+	r.POST("/webmention", c.Receive)
+	r.GET("/webmention", guard.Optional, c.FindByPost)
+	r.GET("/webmention/queue", guard.Required, c.FindPending)
+	r.POST("/webmention/approve", guard.Required, c.moderate(StatusApproved))
+	r.POST("/webmention/reject", guard.Required, c.moderate(StatusRejected))
+}
+
+func (c *WebmentionController) Receive(ctx *gin.Context) {
+	source, target := ctx.PostForm("source"), ctx.PostForm("target")
+	if source == "" || target == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing source or target"})
+		return
+	}
+
+	mention, apiErr := c.service.Receive(ctx.Request.Context(), source, target)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":    "webmention accepted",
+		"webmention": mention,
+	})
+}
+
+func (c *WebmentionController) FindByPost(ctx *gin.Context) {
+	postId := ctx.Query("postId")
+	if postId == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing postId"})
+		return
+	}
+
+	limit, cursor, ok := paging(ctx)
+	if !ok {
+		return
+	}
+
+	mentions, page, apiErr := c.service.FindByPost(ctx.Request.Context(), postId, limit, cursor)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	writePage(ctx, "webmentions found", mentions, page)
+}
+
+func (c *WebmentionController) FindPending(ctx *gin.Context) {
+	limit, cursor, ok := paging(ctx)
+	if !ok {
+		return
+	}
+
+	mentions, page, apiErr := c.service.FindPending(ctx.Request.Context(), limit, cursor)
+	if apiErr != nil {
+		ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+		return
+	}
+
+	writePage(ctx, "pending webmentions found", mentions, page)
+}
+
+func (c *WebmentionController) moderate(status Status) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Query("id")
+		if id == "" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "missing id"})
+			return
+		}
+
+		mention, apiErr := c.service.Moderate(ctx.Request.Context(), id, status)
+		if apiErr != nil {
+			ctx.AbortWithStatusJSON(apiErr.Code, gin.H{"message": apiErr.Message})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "webmention " + string(status),
+			"webmention": mention,
+		})
+	}
+}
+
+func writePage(ctx *gin.Context, message string, mentions []Webmention, page pagination.Page) {
+	if link := pagination.LinkHeader(ctx.Request.URL, page); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     message,
+		"webmentions": mentions,
+		"next":        page.Next,
+		"prev":        page.Prev,
+	})
+}
+
+func paging(ctx *gin.Context) (int, *pagination.Cursor, bool) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+		return 0, nil, false
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var cursor *pagination.Cursor
+	if token := ctx.Query("cursor"); token != "" {
+		decoded, err := pagination.Decode(token)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid cursor"})
+			return 0, nil, false
+		}
+		cursor = &decoded
+	}
+
+	return limit, cursor, true
+}
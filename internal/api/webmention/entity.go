@@ -0,0 +1,56 @@
+package webmention
+
+import "time"
+
+type Status string
+
+const (
+	StatusUnverified Status = "unverified"
+	StatusPending    Status = "pending"
+	StatusApproved   Status = "approved"
+	StatusRejected   Status = "rejected"
+	StatusInvalid    Status = "invalid"
+)
+
+type Type string
+
+const (
+	TypeMention  Type = "mention"
+	TypeReply    Type = "reply"
+	TypeLike     Type = "like"
+	TypeRepost   Type = "repost"
+	TypeBookmark Type = "bookmark"
+)
+
+type Webmention struct {
+	Id         string     `json:"id"`
+	PostId     string     `json:"postId"`
+	Source     string     `json:"source"`
+	Target     string     `json:"target"`
+	Type       Type       `json:"type"`
+	Status     Status     `json:"status"`
+	Title      string     `json:"title,omitempty"`
+	AuthorName string     `json:"authorName,omitempty"`
+	AuthorURL  string     `json:"authorUrl,omitempty"`
+	Content    string     `json:"content,omitempty"`
+	Attempts   int        `json:"-"`
+	VerifiedAt *time.Time `json:"verifiedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+type Send struct {
+	Id       int64
+	Source   string
+	Target   string
+	Attempts int
+}
+
+// Mention is what verification learned about a source document.
+type Mention struct {
+	Type       Type
+	Title      string
+	AuthorName string
+	AuthorURL  string
+	Content    string
+}
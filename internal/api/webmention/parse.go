@@ -0,0 +1,209 @@
+package webmention
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const contentLength = 500
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]{}]+`)
+
+// links returns the distinct absolute http(s) URLs found in post content,
+// whether written as HTML anchors, Markdown links or bare URLs.
+func links(content string) []string {
+	var found []string
+	for _, link := range linkPattern.FindAllString(content, -1) {
+		link = strings.TrimRight(link, ".,;:!?*_")
+		if !slices.Contains(found, link) {
+			found = append(found, link)
+		}
+	}
+	return found
+}
+
+// inspect looks for target in an HTML source document. It reports whether
+// the document links to it and reads the microformats2 properties needed to
+// display the response: the link's class decides its type, while the first
+// p-name, p-author and e-content give the title, author and content.
+func inspect(r io.Reader, base *url.URL, target string) (Mention, bool) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Mention{}, false
+	}
+
+	mention := Mention{Type: TypeMention}
+	var linked bool
+	var title string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			classes := strings.Fields(attr(n, "class"))
+
+			switch {
+			case n.Data == "title" && title == "":
+				title = text(n)
+			case slices.Contains(classes, "p-name") && mention.Title == "":
+				mention.Title = text(n)
+			case slices.Contains(classes, "p-author") && mention.AuthorName == "":
+				mention.AuthorName = text(n)
+				if href := attr(n, "href"); href != "" {
+					mention.AuthorURL = resolve(base, href)
+				}
+			case slices.Contains(classes, "e-content") && mention.Content == "":
+				mention.Content = truncate(text(n))
+			}
+
+			if n.Data == "a" || n.Data == "link" {
+				if href := attr(n, "href"); href != "" && sameURL(resolve(base, href), target) {
+					linked = true
+					if kind := linkType(classes); kind != TypeMention {
+						mention.Type = kind
+					}
+				}
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if mention.Title == "" {
+		mention.Title = title
+	}
+	return mention, linked
+}
+
+func linkType(classes []string) Type {
+	for _, class := range classes {
+		switch class {
+		case "u-in-reply-to":
+			return TypeReply
+		case "u-like-of":
+			return TypeLike
+		case "u-repost-of":
+			return TypeRepost
+		case "u-bookmark-of":
+			return TypeBookmark
+		}
+	}
+	return TypeMention
+}
+
+// htmlEndpoint finds a link or anchor with rel="webmention" in an HTML document.
+func htmlEndpoint(r io.Reader, base *url.URL) string {
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data != "link" && token.Data != "a" {
+				continue
+			}
+
+			var rel, href string
+			var hasHref bool
+			for _, a := range token.Attr {
+				switch a.Key {
+				case "rel":
+					rel = a.Val
+				case "href":
+					href, hasHref = a.Val, true
+				}
+			}
+			if hasHref && slices.Contains(strings.Fields(strings.ToLower(rel)), "webmention") {
+				return resolve(base, href)
+			}
+		}
+	}
+}
+
+// linkHeaderEndpoint finds rel="webmention" in RFC 8288 Link headers.
+func linkHeaderEndpoint(headers []string, base *url.URL) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			href := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(href, "<") || !strings.HasSuffix(href, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(name, "rel") {
+					continue
+				}
+				if slices.Contains(strings.Fields(strings.ToLower(strings.Trim(value, `"`))), "webmention") {
+					return resolve(base, strings.Trim(href, "<>"))
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func truncate(s string) string {
+	if utf8.RuneCountInString(s) <= contentLength {
+		return s
+	}
+	return string([]rune(s)[:contentLength]) + "…"
+}
+
+func resolve(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func sameURL(a, b string) bool {
+	return normalize(a) == normalize(b)
+}
+
+func normalize(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
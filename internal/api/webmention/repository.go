@@ -0,0 +1,239 @@
+package webmention
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+)
+
+type WebmentionRepository interface {
+	Save(ctx context.Context, postId, source, target string) (Webmention, error)
+	FindById(ctx context.Context, id string) (Webmention, error)
+	FindByPost(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Webmention, error)
+	FindPending(ctx context.Context, limit int, cursor *pagination.Cursor) ([]Webmention, error)
+	SetStatus(ctx context.Context, id string, status Status) (Webmention, error)
+	ClaimVerifications(ctx context.Context, limit int, lease time.Duration) ([]Webmention, error)
+	Verified(ctx context.Context, id string, mention Mention) error
+	Invalidate(ctx context.Context, id, reason string) error
+	RetryVerification(ctx context.Context, id string, attempts int, next time.Time, lastError string) error
+	QueueSends(ctx context.Context, source string, targets []string) error
+	ClaimSends(ctx context.Context, limit int, lease time.Duration) ([]Send, error)
+	CompleteSend(ctx context.Context, id int64) error
+	RetrySend(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error
+}
+
+const webmentionColumns = `w.id, w.post_id, w.source, w.target, w.type, w.status, w.title, w.author_name, w.author_url, w.content, w.attempts, w.verified_at, w.created_at, w.updated_at`
+
+type PostgresWebmentionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresWebmentionRepository(pool *pgxpool.Pool) *PostgresWebmentionRepository {
+	return &PostgresWebmentionRepository{pool: pool}
+}
+
+// Save records a received mention, or re-queues an existing one for
+// verification when the sender reports that the source changed.
+func (r *PostgresWebmentionRepository) Save(ctx context.Context, postId, source, target string) (Webmention, error) {
+	query := `
+		INSERT INTO webmentions AS w (post_id, source, target)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (source, target) DO UPDATE
+		SET post_id = EXCLUDED.post_id,
+			status = 'unverified',
+			attempts = 0,
+			next_attempt_at = now(),
+			last_error = NULL,
+			updated_at = now()
+		RETURNING ` + webmentionColumns
+
+	return scanWebmention(r.pool.QueryRow(ctx, query, postId, source, target))
+}
+
+func (r *PostgresWebmentionRepository) FindById(ctx context.Context, id string) (Webmention, error) {
+	query := `
+		SELECT ` + webmentionColumns + `
+		FROM webmentions w
+		WHERE w.id = $1
+	`
+
+	return scanWebmention(r.pool.QueryRow(ctx, query, id))
+}
+
+func (r *PostgresWebmentionRepository) FindByPost(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Webmention, error) {
+	page, args := pagination.Keyset(cursor, "w", 2, limit)
+	query := `
+		SELECT ` + webmentionColumns + `
+		FROM webmentions w
+		WHERE w.post_id = $1
+		  AND w.status = 'approved'` + page
+
+	return r.query(ctx, query, append([]any{postId}, args...)...)
+}
+
+func (r *PostgresWebmentionRepository) FindPending(ctx context.Context, limit int, cursor *pagination.Cursor) ([]Webmention, error) {
+	page, args := pagination.Keyset(cursor, "w", 1, limit)
+	query := `
+		SELECT ` + webmentionColumns + `
+		FROM webmentions w
+		WHERE w.status = 'pending'` + page
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *PostgresWebmentionRepository) SetStatus(ctx context.Context, id string, status Status) (Webmention, error) {
+	query := `
+		UPDATE webmentions w
+		SET status = $2,
+			approved_at = CASE WHEN $2 = 'approved' THEN now() END,
+			updated_at = now()
+		WHERE w.id = $1
+		RETURNING ` + webmentionColumns
+
+	return scanWebmention(r.pool.QueryRow(ctx, query, id, status))
+}
+
+// ClaimVerifications leases due unverified mentions by pushing their next
+// attempt past the lease so replicas never verify the same one concurrently.
+func (r *PostgresWebmentionRepository) ClaimVerifications(ctx context.Context, limit int, lease time.Duration) ([]Webmention, error) {
+	query := `
+		UPDATE webmentions w
+		SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		FROM (
+			SELECT id
+			FROM webmentions
+			WHERE status = 'unverified' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE w.id = due.id
+		RETURNING ` + webmentionColumns
+
+	return r.query(ctx, query, limit, lease.Milliseconds())
+}
+
+// Verified keeps a previously approved mention approved when its source is
+// updated; anything else waits for a moderator.
+func (r *PostgresWebmentionRepository) Verified(ctx context.Context, id string, mention Mention) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webmentions
+		SET type = $2,
+			title = $3,
+			author_name = $4,
+			author_url = $5,
+			content = $6,
+			status = CASE WHEN approved_at IS NOT NULL THEN 'approved' ELSE 'pending' END,
+			last_error = NULL,
+			verified_at = now(),
+			updated_at = now()
+		WHERE id = $1
+	`, id, mention.Type, mention.Title, mention.AuthorName, mention.AuthorURL, mention.Content)
+	return err
+}
+
+func (r *PostgresWebmentionRepository) Invalidate(ctx context.Context, id, reason string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webmentions
+		SET status = 'invalid', last_error = $2, updated_at = now()
+		WHERE id = $1
+	`, id, reason)
+	return err
+}
+
+func (r *PostgresWebmentionRepository) RetryVerification(ctx context.Context, id string, attempts int, next time.Time, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webmentions
+		SET attempts = $2, next_attempt_at = $3, last_error = $4
+		WHERE id = $1
+	`, id, attempts, next, lastError)
+	return err
+}
+
+func (r *PostgresWebmentionRepository) QueueSends(ctx context.Context, source string, targets []string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO webmention_sends (source, target)
+		SELECT $1, target
+		FROM unnest($2::text[]) AS target
+		ON CONFLICT (source, target) DO UPDATE
+		SET attempts = 0, next_attempt_at = now(), last_error = NULL
+	`, source, targets)
+	return err
+}
+
+func (r *PostgresWebmentionRepository) ClaimSends(ctx context.Context, limit int, lease time.Duration) ([]Send, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE webmention_sends s
+		SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		FROM (
+			SELECT id
+			FROM webmention_sends
+			WHERE next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE s.id = due.id
+		RETURNING s.id, s.source, s.target, s.attempts
+	`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sends []Send
+	for rows.Next() {
+		var send Send
+		if err := rows.Scan(&send.Id, &send.Source, &send.Target, &send.Attempts); err != nil {
+			return nil, err
+		}
+		sends = append(sends, send)
+	}
+
+	return sends, rows.Err()
+}
+
+func (r *PostgresWebmentionRepository) CompleteSend(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM webmention_sends
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *PostgresWebmentionRepository) RetrySend(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webmention_sends
+		SET attempts = $2, next_attempt_at = $3, last_error = $4
+		WHERE id = $1
+	`, id, attempts, next, lastError)
+	return err
+}
+
+func (r *PostgresWebmentionRepository) query(ctx context.Context, query string, args ...any) ([]Webmention, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []Webmention
+	for rows.Next() {
+		mention, err := scanWebmention(rows)
+		if err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, mention)
+	}
+
+	return mentions, rows.Err()
+}
+
+func scanWebmention(row pgx.Row) (Webmention, error) {
+	var w Webmention
+	err := row.Scan(&w.Id, &w.PostId, &w.Source, &w.Target, &w.Type, &w.Status, &w.Title, &w.AuthorName, &w.AuthorURL, &w.Content, &w.Attempts, &w.VerifiedAt, &w.CreatedAt, &w.UpdatedAt)
+	return w, err
+}
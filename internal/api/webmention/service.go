@@ -0,0 +1,329 @@
+package webmention
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+	"github.com/joaopdias/blog-server/internal/shared/pagination"
+	"github.com/joaopdias/blog-server/internal/shared/safehttp"
+)
+
+const (
+	maxBodySize  = 1 << 20
+	fetchTimeout = 10 * time.Second
+	userAgent    = "blog-server webmention"
+)
+
+// Client fetches sources and targets; a local stand-in can replace the
+// default client to exercise verification and discovery. The default one
+// only connects to public addresses, since sources are submitted by anyone.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// retryable marks failures worth another attempt, such as network errors
+// and 5xx responses; anything else is final.
+type retryable struct {
+	err error
+}
+
+func (r retryable) Error() string {
+	return r.err.Error()
+}
+
+type WebmentionService struct {
+	repository  WebmentionRepository
+	postService *post.PostService
+	client      Client
+	siteURL     string
+	host        string
+}
+
+func NewWebmentionService(repo WebmentionRepository, postService *post.PostService, client Client, siteURL string) *WebmentionService {
+	if client == nil {
+		client = safehttp.NewClient(fetchTimeout)
+	}
+
+	siteURL = strings.TrimRight(siteURL, "/")
+	var host string
+	if u, err := url.Parse(siteURL); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+
+	return &WebmentionService{
+		repository:  repo,
+		postService: postService,
+		client:      client,
+		siteURL:     siteURL,
+		host:        host,
+	}
+}
+
+// Receive accepts a mention of one of our posts. The source is fetched and
+// checked later by the worker, as the spec recommends.
+func (s *WebmentionService) Receive(ctx context.Context, source, target string) (Webmention, *errors.ApiError) {
+	sourceURL, ok := parseURL(source)
+	if !ok || !safehttp.IsPublicHost(sourceURL.Hostname()) {
+		return Webmention{}, errors.NewApiError(http.StatusBadRequest, "invalid source")
+	}
+	targetURL, ok := parseURL(target)
+	if !ok {
+		return Webmention{}, errors.NewApiError(http.StatusBadRequest, "invalid target")
+	}
+	if sameURL(sourceURL.String(), targetURL.String()) {
+		return Webmention{}, errors.NewApiError(http.StatusBadRequest, "source and target must differ")
+	}
+
+	slug, ok := strings.CutPrefix(strings.TrimSuffix(targetURL.Path, "/"), "/posts/")
+	if strings.ToLower(targetURL.Host) != s.host || !ok || slug == "" || strings.Contains(slug, "/") {
+		return Webmention{}, errors.NewApiError(http.StatusBadRequest, "target does not accept webmentions")
+	}
+
+	mentioned, apiErr := s.postService.FindBySlug(ctx, slug)
+	if apiErr != nil {
+		return Webmention{}, errors.NewApiError(http.StatusBadRequest, "target does not accept webmentions")
+	}
+
+	mention, err := s.repository.Save(ctx, mentioned.ID, sourceURL.String(), targetURL.String())
+	if err != nil {
+		return Webmention{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return mention, nil
+}
+
+func (s *WebmentionService) FindByPost(ctx context.Context, postId string, limit int, cursor *pagination.Cursor) ([]Webmention, pagination.Page, *errors.ApiError) {
+	if _, apiErr := s.postService.FindById(ctx, postId); apiErr != nil {
+		return nil, pagination.Page{}, apiErr
+	}
+
+	mentions, err := s.repository.FindByPost(ctx, postId, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	mentions, page := pagination.Paginate(mentions, limit, cursor, webmentionKey)
+	return mentions, page, nil
+}
+
+func (s *WebmentionService) FindPending(ctx context.Context, limit int, cursor *pagination.Cursor) ([]Webmention, pagination.Page, *errors.ApiError) {
+	if _, apiErr := user.Authorize(ctx, user.User.CanModerate); apiErr != nil {
+		return nil, pagination.Page{}, apiErr
+	}
+
+	mentions, err := s.repository.FindPending(ctx, limit, cursor)
+	if err != nil {
+		return nil, pagination.Page{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	mentions, page := pagination.Paginate(mentions, limit, cursor, webmentionKey)
+	return mentions, page, nil
+}
+
+// Moderate approves or rejects a verified mention. Moderators can act on
+// any mention and authors on mentions of their own posts.
+func (s *WebmentionService) Moderate(ctx context.Context, id string, status Status) (Webmention, *errors.ApiError) {
+	mention, err := s.repository.FindById(ctx, id)
+	if err != nil {
+		return Webmention{}, errors.NewApiError(http.StatusNotFound, "webmention not found")
+	}
+
+	target, apiErr := s.postService.FindById(ctx, mention.PostId)
+	if apiErr != nil {
+		return Webmention{}, errors.NewApiError(http.StatusNotFound, "webmention not found")
+	}
+
+	if _, apiErr := user.Authorize(ctx, func(u user.User) bool {
+		return u.CanModerate() || u.CanManagePost(target.AuthorId)
+	}); apiErr != nil {
+		return Webmention{}, apiErr
+	}
+
+	if mention.Status == StatusUnverified || mention.Status == StatusInvalid {
+		return Webmention{}, errors.NewApiError(http.StatusConflict, "webmention is not verified")
+	}
+
+	mention, err = s.repository.SetStatus(ctx, id, status)
+	if err != nil {
+		return Webmention{}, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return mention, nil
+}
+
+// Verify fetches a mention's source and checks that it still links to the
+// target. Sources that are gone or no longer link are marked invalid, which
+// also hides mentions whose source was deleted after approval.
+func (s *WebmentionService) Verify(ctx context.Context, mention Webmention) error {
+	resp, err := s.fetch(ctx, http.MethodGet, mention.Source, nil)
+	if err != nil {
+		return retryable{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return retryable{fmt.Errorf("source responded %d", resp.StatusCode)}
+	}
+	if resp.StatusCode >= 300 {
+		return s.repository.Invalidate(ctx, mention.Id, fmt.Sprintf("source responded %d", resp.StatusCode))
+	}
+
+	body := io.LimitReader(resp.Body, maxBodySize)
+	var found Mention
+	var linked bool
+
+	if isHTML(resp.Header.Get("Content-Type")) {
+		found, linked = inspect(body, finalURL(resp), mention.Target)
+	} else {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return retryable{err}
+		}
+		found, linked = Mention{Type: TypeMention}, strings.Contains(string(data), mention.Target)
+	}
+
+	if !linked {
+		return s.repository.Invalidate(ctx, mention.Id, "source does not link to target")
+	}
+
+	return s.repository.Verified(ctx, mention.Id, found)
+}
+
+// Send discovers the target's Webmention endpoint and notifies it. Targets
+// without an endpoint are done.
+func (s *WebmentionService) Send(ctx context.Context, send Send) error {
+	endpoint, err := s.discover(ctx, send.Target)
+	if err != nil || endpoint == "" {
+		return err
+	}
+
+	form := url.Values{"source": {send.Source}, "target": {send.Target}}
+	resp, err := s.fetch(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return retryable{err}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return retryable{fmt.Errorf("endpoint responded %d", resp.StatusCode)}
+	default:
+		return fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+}
+
+func (s *WebmentionService) discover(ctx context.Context, target string) (string, error) {
+	resp, err := s.fetch(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", retryable{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return "", retryable{fmt.Errorf("target responded %d", resp.StatusCode)}
+	}
+	if resp.StatusCode >= 300 {
+		return "", nil
+	}
+
+	base := finalURL(resp)
+	if found := linkHeaderEndpoint(resp.Header.Values("Link"), base); found != "" {
+		return found, nil
+	}
+	if isHTML(resp.Header.Get("Content-Type")) {
+		return htmlEndpoint(io.LimitReader(resp.Body, maxBodySize), base), nil
+	}
+	return "", nil
+}
+
+func (s *WebmentionService) fetch(ctx context.Context, method, uri string, body io.Reader) (*http.Response, error) {
+	if _, ok := parseURL(uri); !ok {
+		return nil, fmt.Errorf("invalid url %q", uri)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req.Header.Set("Accept", "text/html, */*;q=0.5")
+	}
+
+	return s.client.Do(req)
+}
+
+func (s *WebmentionService) PostPublished(ctx context.Context, published post.Post) {
+	s.queue(ctx, published)
+}
+
+func (s *WebmentionService) PostUpdated(ctx context.Context, updated post.Post) {
+	s.queue(ctx, updated)
+}
+
+// PostDeleted notifies the same targets again so they notice the source is
+// gone and drop the mention.
+func (s *WebmentionService) PostDeleted(ctx context.Context, deleted post.Post) {
+	s.queue(ctx, deleted)
+}
+
+func (s *WebmentionService) queue(ctx context.Context, p post.Post) {
+	if !p.IsVisible() {
+		return
+	}
+
+	var targets []string
+	for _, link := range links(p.Content) {
+		if u, ok := parseURL(link); ok && strings.ToLower(u.Host) != s.host {
+			targets = append(targets, link)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	if err := s.repository.QueueSends(ctx, s.siteURL+"/posts/"+p.Slug, targets); err != nil {
+		log.Printf("webmention: failed to queue mentions for post %s: %s", p.ID, err)
+	}
+}
+
+func parseURL(raw string) (*url.URL, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false
+	}
+	return u, true
+}
+
+// finalURL is the URL a response was served from after redirects, which
+// relative links in it resolve against.
+func finalURL(resp *http.Response) *url.URL {
+	if resp.Request != nil {
+		return resp.Request.URL
+	}
+	return &url.URL{}
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+func webmentionKey(mention Webmention) (time.Time, string) {
+	return mention.CreatedAt, mention.Id
+}
@@ -0,0 +1,263 @@
+package webmention
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const target = "https://blog.example/posts/hello"
+
+type fakeRepository struct {
+	WebmentionRepository
+
+	mu          sync.Mutex
+	claimed     []Webmention
+	verified    map[string]Mention
+	invalidated map[string]string
+	retried     map[string]int
+}
+
+func newFakeRepository(claimed ...Webmention) *fakeRepository {
+	return &fakeRepository{
+		claimed:     claimed,
+		verified:    map[string]Mention{},
+		invalidated: map[string]string{},
+		retried:     map[string]int{},
+	}
+}
+
+func (f *fakeRepository) ClaimVerifications(_ context.Context, _ int, _ time.Duration) ([]Webmention, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	claimed := f.claimed
+	f.claimed = nil
+	return claimed, nil
+}
+
+func (f *fakeRepository) Verified(_ context.Context, id string, mention Mention) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verified[id] = mention
+	return nil
+}
+
+func (f *fakeRepository) Invalidate(_ context.Context, id, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalidated[id] = reason
+	return nil
+}
+
+func (f *fakeRepository) RetryVerification(_ context.Context, id string, attempts int, _ time.Time, _ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retried[id] = attempts
+	return nil
+}
+
+func newTestService(repo WebmentionRepository, client Client) *WebmentionService {
+	return NewWebmentionService(repo, nil, client, "https://blog.example")
+}
+
+func serve(status int, contentType, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestVerifyLinkingSource(t *testing.T) {
+	source := serve(http.StatusOK, "text/html; charset=utf-8", `<html><head><title>A page</title></head>
+		<body><p>I liked <a href="`+target+`">this post</a>.</p></body></html>`)
+	defer source.Close()
+
+	repo := newFakeRepository()
+	service := newTestService(repo, source.Client())
+
+	if err := service.Verify(context.Background(), Webmention{Id: "w1", Source: source.URL, Target: target}); err != nil {
+		t.Fatal(err)
+	}
+
+	mention, ok := repo.verified["w1"]
+	if !ok {
+		t.Fatalf("mention not verified, invalidated: %v", repo.invalidated)
+	}
+	if mention.Type != TypeMention || mention.Title != "A page" {
+		t.Errorf("unexpected mention %+v", mention)
+	}
+}
+
+func TestVerifySourceWithoutLink(t *testing.T) {
+	source := serve(http.StatusOK, "text/html", `<p>Nothing to see, just <a href="https://blog.example/posts/other">another post</a>.</p>`)
+	defer source.Close()
+
+	repo := newFakeRepository()
+	service := newTestService(repo, source.Client())
+
+	if err := service.Verify(context.Background(), Webmention{Id: "w1", Source: source.URL, Target: target}); err != nil {
+		t.Fatal(err)
+	}
+	if reason := repo.invalidated["w1"]; reason != "source does not link to target" {
+		t.Fatalf("invalidated = %q, verified %v", reason, repo.verified)
+	}
+}
+
+func TestVerifySourceGone(t *testing.T) {
+	source := serve(http.StatusGone, "text/html", `<a href="`+target+`">gone</a>`)
+	defer source.Close()
+
+	repo := newFakeRepository()
+	service := newTestService(repo, source.Client())
+
+	if err := service.Verify(context.Background(), Webmention{Id: "w1", Source: source.URL, Target: target}); err != nil {
+		t.Fatal(err)
+	}
+	if reason := repo.invalidated["w1"]; !strings.Contains(reason, "410") {
+		t.Fatalf("invalidated = %q", reason)
+	}
+	if len(repo.verified) != 0 {
+		t.Fatal("gone source was verified")
+	}
+}
+
+func TestVerifyServerErrorIsRetried(t *testing.T) {
+	source := serve(http.StatusServiceUnavailable, "text/plain", "try later")
+	defer source.Close()
+
+	repo := newFakeRepository(Webmention{Id: "w1", Source: source.URL, Target: target, Attempts: 1})
+	service := newTestService(repo, source.Client())
+
+	err := service.Verify(context.Background(), Webmention{Id: "w1", Source: source.URL, Target: target})
+	if !isRetryable(err) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+
+	NewWorker(service, repo, time.Minute).verify(context.Background())
+	if repo.retried["w1"] != 2 {
+		t.Fatalf("retried = %v, want attempt 2 for w1", repo.retried)
+	}
+	if len(repo.invalidated) != 0 || len(repo.verified) != 0 {
+		t.Fatal("a 5xx response decided the mention")
+	}
+}
+
+func TestVerifyReadsMicroformats(t *testing.T) {
+	source := serve(http.StatusOK, "text/html", `<html><head><title>Page title</title></head><body>
+		<article class="h-entry">
+			<h1 class="p-name">Re: Hello</h1>
+			<a class="p-author h-card" href="/about">Grace</a>
+			<a class="u-in-reply-to" href="`+target+`">in reply to</a>
+			<div class="e-content"><p>Great   post,
+			thanks!</p></div>
+		</article>
+	</body></html>`)
+	defer source.Close()
+
+	repo := newFakeRepository()
+	service := newTestService(repo, source.Client())
+
+	if err := service.Verify(context.Background(), Webmention{Id: "w1", Source: source.URL + "/notes/1", Target: target}); err != nil {
+		t.Fatal(err)
+	}
+
+	mention := repo.verified["w1"]
+	want := Mention{
+		Type:       TypeReply,
+		Title:      "Re: Hello",
+		AuthorName: "Grace",
+		AuthorURL:  source.URL + "/about",
+		Content:    "Great post, thanks!",
+	}
+	if mention != want {
+		t.Fatalf("mention = %+v, want %+v", mention, want)
+	}
+}
+
+func TestSendDiscovery(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		body   string
+		path   string
+	}{
+		{name: "link header", header: `</endpoint/header>; rel="webmention"`, body: `<p>no links</p>`, path: "/endpoint/header"},
+		{name: "link element", body: `<html><head><link rel="webmention" href="/endpoint/link"></head></html>`, path: "/endpoint/link"},
+		{name: "anchor", body: `<p><a rel="nofollow webmention" href="/endpoint/anchor">mention me</a></p>`, path: "/endpoint/anchor"},
+		{name: "relative to page", body: `<link rel="webmention" href="../endpoint/relative">`, path: "/endpoint/relative"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var hits []string
+			var source, notified string
+
+			site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if r.Method == http.MethodPost {
+					hits = append(hits, r.URL.Path)
+					r.ParseForm()
+					source, notified = r.PostForm.Get("source"), r.PostForm.Get("target")
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+
+				if tc.header != "" {
+					w.Header().Set("Link", tc.header)
+				}
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(tc.body))
+			}))
+			defer site.Close()
+
+			service := newTestService(newFakeRepository(), site.Client())
+			page := site.URL + "/articles/page"
+
+			err := service.Send(context.Background(), Send{Id: 1, Source: target, Target: page})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(hits) != 1 || hits[0] != tc.path {
+				t.Fatalf("endpoint hits = %v, want %s", hits, tc.path)
+			}
+			if source != target || notified != page {
+				t.Errorf("notified source=%q target=%q", source, notified)
+			}
+		})
+	}
+}
+
+func TestSendWithoutEndpoint(t *testing.T) {
+	site := serve(http.StatusOK, "text/html", `<p>No endpoint here.</p>`)
+	defer site.Close()
+
+	service := newTestService(newFakeRepository(), site.Client())
+	if err := service.Send(context.Background(), Send{Source: target, Target: site.URL}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestReceiveRejectsInternalSources(t *testing.T) {
+	service := newTestService(newFakeRepository(), nil)
+
+	for _, source := range []string{
+		"http://localhost/page",
+		"http://127.0.0.1:8080/page",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/",
+		"file:///etc/passwd",
+	} {
+		if _, apiErr := service.Receive(context.Background(), source, target); apiErr == nil || apiErr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %v", source, apiErr)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package webmention
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"time"
+)
+
+const (
+	workerBatchSize = 50
+	workerLease     = 5 * time.Minute
+	maxAttempts     = 6
+)
+
+type Worker struct {
+	service    *WebmentionService
+	repository WebmentionRepository
+	interval   time.Duration
+}
+
+func NewWorker(service *WebmentionService, repo WebmentionRepository, interval time.Duration) *Worker {
+	return &Worker{service: service, repository: repo, interval: interval}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.verify(ctx)
+		w.send(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) verify(ctx context.Context) {
+	for {
+		mentions, err := w.repository.ClaimVerifications(ctx, workerBatchSize, workerLease)
+		if err != nil {
+			log.Printf("webmention: failed to claim verifications: %s", err)
+			return
+		}
+
+		for _, mention := range mentions {
+			err := w.service.Verify(ctx, mention)
+			switch {
+			case err == nil:
+				continue
+			case !isRetryable(err):
+				log.Printf("webmention: failed to verify mention %s: %s", mention.Id, err)
+				continue
+			}
+
+			attempts := mention.Attempts + 1
+			if attempts >= maxAttempts {
+				err = w.repository.Invalidate(ctx, mention.Id, err.Error())
+			} else {
+				err = w.repository.RetryVerification(ctx, mention.Id, attempts, time.Now().Add(retryDelay(attempts)), err.Error())
+			}
+			if err != nil {
+				log.Printf("webmention: failed to update mention %s: %s", mention.Id, err)
+			}
+		}
+
+		if len(mentions) < workerBatchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) send(ctx context.Context) {
+	for {
+		sends, err := w.repository.ClaimSends(ctx, workerBatchSize, workerLease)
+		if err != nil {
+			log.Printf("webmention: failed to claim sends: %s", err)
+			return
+		}
+
+		for _, send := range sends {
+			err := w.service.Send(ctx, send)
+
+			attempts := send.Attempts + 1
+			if err != nil && isRetryable(err) && attempts < maxAttempts {
+				err = w.repository.RetrySend(ctx, send.Id, attempts, time.Now().Add(retryDelay(attempts)), err.Error())
+			} else {
+				if err != nil {
+					log.Printf("webmention: giving up on %s -> %s: %s", send.Source, send.Target, err)
+				}
+				err = w.repository.CompleteSend(ctx, send.Id)
+			}
+			if err != nil {
+				log.Printf("webmention: failed to update send %d: %s", send.Id, err)
+			}
+		}
+
+		if len(sends) < workerBatchSize {
+			return
+		}
+	}
+}
+
+func isRetryable(err error) bool {
+	var r retryable
+	return stderrors.As(err, &r)
+}
+
+// retryDelay waits 30s, 2m, 8m, ... between attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 4
+	}
+	return delay
+}
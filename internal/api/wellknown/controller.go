@@ -0,0 +1,25 @@
+package wellknown
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/shared/auth"
+)
+
+type WellKnownController struct {
+	keys *auth.KeySet
+}
+
+func NewWellKnownController(keys *auth.KeySet) *WellKnownController {
+	return &WellKnownController{keys: keys}
+}
+
+func (c *WellKnownController) RegisterRoutes(r *gin.Engine) {
+	r.GET("/.well-known/jwks.json", c.JWKS)
+}
+
+func (c *WellKnownController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.keys.JWKS())
+}
@@ -0,0 +1,221 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault codes from the XML-RPC interoperability spec, used for failures
+// that happen before a method runs.
+const (
+	faultParse          = -32700
+	faultMethodNotFound = -32601
+)
+
+type Fault struct {
+	Code   int
+	String string
+}
+
+type node struct {
+	XMLName xml.Name
+	Content string `xml:",chardata"`
+	Nodes   []node `xml:",any"`
+}
+
+func (n node) child(name string) (node, bool) {
+	for _, child := range n.Nodes {
+		if child.XMLName.Local == name {
+			return child, true
+		}
+	}
+	return node{}, false
+}
+
+func decodeCall(r io.Reader) (string, []any, error) {
+	var call node
+	if err := xml.NewDecoder(r).Decode(&call); err != nil {
+		return "", nil, err
+	}
+	if call.XMLName.Local != "methodCall" {
+		return "", nil, fmt.Errorf("expected methodCall")
+	}
+
+	name, ok := call.child("methodName")
+	if !ok || strings.TrimSpace(name.Content) == "" {
+		return "", nil, fmt.Errorf("missing methodName")
+	}
+
+	var params []any
+	if list, ok := call.child("params"); ok {
+		for _, param := range list.Nodes {
+			value, ok := param.child("value")
+			if !ok {
+				return "", nil, fmt.Errorf("param without value")
+			}
+			decoded, err := decodeValue(value)
+			if err != nil {
+				return "", nil, err
+			}
+			params = append(params, decoded)
+		}
+	}
+
+	return strings.TrimSpace(name.Content), params, nil
+}
+
+func decodeValue(value node) (any, error) {
+	if len(value.Nodes) == 0 {
+		return value.Content, nil
+	}
+
+	typed := value.Nodes[0]
+	text := strings.TrimSpace(typed.Content)
+
+	switch typed.XMLName.Local {
+	case "string":
+		return typed.Content, nil
+	case "int", "i4", "i8":
+		return strconv.Atoi(text)
+	case "boolean":
+		switch text {
+		case "1":
+			return true, nil
+		case "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", text)
+	case "double":
+		return strconv.ParseFloat(text, 64)
+	case "dateTime.iso8601":
+		return parseTime(text)
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	case "nil":
+		return nil, nil
+	case "struct":
+		members := map[string]any{}
+		for _, member := range typed.Nodes {
+			name, _ := member.child("name")
+			value, ok := member.child("value")
+			if !ok {
+				return nil, fmt.Errorf("struct member without value")
+			}
+			decoded, err := decodeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			members[strings.TrimSpace(name.Content)] = decoded
+		}
+		return members, nil
+	case "array":
+		items := []any{}
+		data, _ := typed.child("data")
+		for _, item := range data.Nodes {
+			decoded, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, decoded)
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("unknown type %q", typed.XMLName.Local)
+}
+
+// parseTime accepts the compact form the spec shows as well as the dashed
+// and zoned variants desktop editors actually send.
+func parseTime(text string) (time.Time, error) {
+	for _, layout := range []string{"20060102T15:04:05", "20060102T15:04:05Z07:00", "2006-01-02T15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid dateTime %q", text)
+}
+
+func encodeResponse(value any) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodResponse><params><param>")
+	encodeValue(&b, value)
+	b.WriteString("</param></params></methodResponse>")
+	return b.Bytes()
+}
+
+func encodeFault(fault Fault) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodResponse><fault>")
+	encodeValue(&b, map[string]any{"faultCode": fault.Code, "faultString": fault.String})
+	b.WriteString("</fault></methodResponse>")
+	return b.Bytes()
+}
+
+func encodeValue(b *bytes.Buffer, value any) {
+	b.WriteString("<value>")
+
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("<string></string>")
+	case string:
+		b.WriteString("<string>")
+		xml.EscapeText(b, []byte(v))
+		b.WriteString("</string>")
+	case int:
+		fmt.Fprintf(b, "<int>%d</int>", v)
+	case bool:
+		if v {
+			b.WriteString("<boolean>1</boolean>")
+		} else {
+			b.WriteString("<boolean>0</boolean>")
+		}
+	case float64:
+		fmt.Fprintf(b, "<double>%s</double>", strconv.FormatFloat(v, 'f', -1, 64))
+	case time.Time:
+		fmt.Fprintf(b, "<dateTime.iso8601>%s</dateTime.iso8601>", v.UTC().Format("20060102T15:04:05"))
+	case []byte:
+		fmt.Fprintf(b, "<base64>%s</base64>", base64.StdEncoding.EncodeToString(v))
+	case map[string]any:
+		b.WriteString("<struct>")
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			b.WriteString("<member><name>")
+			xml.EscapeText(b, []byte(key))
+			b.WriteString("</name>")
+			encodeValue(b, v[key])
+			b.WriteString("</member>")
+		}
+		b.WriteString("</struct>")
+	case []any:
+		b.WriteString("<array><data>")
+		for _, item := range v {
+			encodeValue(b, item)
+		}
+		b.WriteString("</data></array>")
+	case []string:
+		b.WriteString("<array><data>")
+		for _, item := range v {
+			encodeValue(b, item)
+		}
+		b.WriteString("</data></array>")
+	default:
+		b.WriteString("<string>")
+		xml.EscapeText(b, []byte(fmt.Sprint(v)))
+		b.WriteString("</string>")
+	}
+
+	b.WriteString("</value>")
+}
@@ -0,0 +1,40 @@
+package xmlrpc
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopdias/blog-server/internal/api/media"
+)
+
+// maxBodySize leaves room for a base64-encoded media upload.
+const maxBodySize = media.MaxSize*4/3 + 1<<20
+
+type XMLRPCController struct {
+	service *XMLRPCService
+}
+
+func NewXMLRPCController(service *XMLRPCService) *XMLRPCController {
+	return &XMLRPCController{service: service}
+}
+
+func (c *XMLRPCController) RegisterRoutes(r *gin.Engine) {
+	r.POST("/xmlrpc", c.Call)
+}
+
+// Call answers with 200 even for faults, as XML-RPC requires.
+func (c *XMLRPCController) Call(ctx *gin.Context) {
+	name, params, err := decodeCall(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodySize))
+	if err != nil {
+		ctx.Data(http.StatusOK, "text/xml; charset=utf-8", encodeFault(Fault{Code: faultParse, String: "parse error: " + err.Error()}))
+		return
+	}
+
+	result, fault := c.service.Call(ctx.Request.Context(), name, params)
+	if fault != nil {
+		ctx.Data(http.StatusOK, "text/xml; charset=utf-8", encodeFault(*fault))
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/xml; charset=utf-8", encodeResponse(result))
+}
@@ -0,0 +1,461 @@
+package xmlrpc
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joaopdias/blog-server/internal/api/media"
+	"github.com/joaopdias/blog-server/internal/api/post"
+	"github.com/joaopdias/blog-server/internal/api/user"
+	"github.com/joaopdias/blog-server/internal/shared/errors"
+)
+
+const (
+	blogId         = "1"
+	maxRecentPosts = 100
+)
+
+type method func(ctx context.Context, params args) (any, *errors.ApiError)
+
+type XMLRPCService struct {
+	userService *user.UserService
+	postService *post.PostService
+	media       media.Store
+	siteURL     string
+	title       string
+	methods     map[string]method
+}
+
+func NewXMLRPCService(userService *user.UserService, postService *post.PostService, store media.Store, siteURL, title string) *XMLRPCService {
+	s := &XMLRPCService{
+		userService: userService,
+		postService: postService,
+		media:       store,
+		siteURL:     strings.TrimRight(siteURL, "/"),
+		title:       title,
+	}
+
+	s.methods = map[string]method{
+		"blogger.getUsersBlogs":     s.getUsersBlogs,
+		"blogger.deletePost":        s.deletePost,
+		"metaWeblog.newPost":        s.newPost,
+		"metaWeblog.editPost":       s.editPost,
+		"metaWeblog.getPost":        s.getPost,
+		"metaWeblog.getRecentPosts": s.getRecentPosts,
+		"metaWeblog.getCategories":  s.getCategories,
+		"metaWeblog.newMediaObject": s.newMediaObject,
+	}
+	return s
+}
+
+// Call runs an XML-RPC method. Failures from the services keep their
+// ApiError code as the fault code, the convention WordPress clients expect.
+func (s *XMLRPCService) Call(ctx context.Context, name string, params []any) (any, *Fault) {
+	m, ok := s.methods[name]
+	if !ok {
+		return nil, &Fault{Code: faultMethodNotFound, String: "method not found: " + name}
+	}
+
+	result, apiErr := m(ctx, args(params))
+	if apiErr != nil {
+		return nil, &Fault{Code: apiErr.Code, String: apiErr.Message}
+	}
+	return result, nil
+}
+
+// blogger.getUsersBlogs(appkey, username, password)
+func (s *XMLRPCService) getUsersBlogs(ctx context.Context, params args) (any, *errors.ApiError) {
+	if _, apiErr := s.login(ctx, params, 1); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return []any{map[string]any{
+		"blogid":   blogId,
+		"blogName": s.title,
+		"url":      s.siteURL + "/",
+		"isAdmin":  false,
+	}}, nil
+}
+
+// blogger.deletePost(appkey, postid, username, password, publish)
+func (s *XMLRPCService) deletePost(ctx context.Context, params args) (any, *errors.ApiError) {
+	id, apiErr := params.string(1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	ctx, apiErr = s.login(ctx, params, 2)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := s.postService.Delete(ctx, id); apiErr != nil {
+		return nil, apiErr
+	}
+	return true, nil
+}
+
+// metaWeblog.newPost(blogid, username, password, struct, publish)
+func (s *XMLRPCService) newPost(ctx context.Context, params args) (any, *errors.ApiError) {
+	ctx, apiErr := s.login(ctx, params, 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	content, apiErr := params.content(3)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	publish := params.bool(4, true)
+
+	actor, _ := user.FromContext(ctx)
+	dto := post.CreatePostDTO{
+		Title:    content.string("title"),
+		Slug:     content.string("wp_slug"),
+		Content:  content.string("description"),
+		Tags:     content.tags(),
+		AuthorId: actor.Id,
+		Status:   post.StatusDraft,
+	}
+	if dto.Title == "" || dto.Content == "" {
+		return nil, errors.NewApiError(http.StatusBadRequest, "title and description are required")
+	}
+
+	if publish {
+		dto.Status, dto.PublishedAt = content.schedule(post.StatusPublished)
+	}
+
+	created, apiErr := s.postService.Create(ctx, dto)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return created.ID, nil
+}
+
+// metaWeblog.editPost(postid, username, password, struct, publish)
+func (s *XMLRPCService) editPost(ctx context.Context, params args) (any, *errors.ApiError) {
+	id, apiErr := params.string(0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	ctx, apiErr = s.login(ctx, params, 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	content, apiErr := params.content(3)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	current, apiErr := s.postService.FindById(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var dto post.UpdatePostDTO
+	if title := content.string("title"); title != "" {
+		dto.Title = &title
+	}
+	if description := content.string("description"); description != "" {
+		dto.Content = &description
+	}
+	if slug := content.string("wp_slug"); slug != "" {
+		dto.Slug = &slug
+	}
+	if content.has("categories") || content.has("mt_keywords") {
+		tags := content.tags()
+		dto.Tags = &tags
+	}
+
+	status, ok := content.status()
+	if !ok {
+		return nil, errors.NewApiError(http.StatusBadRequest, "unsupported post_status")
+	}
+	if status == "" {
+		// The publish flag only switches between draft and published, so
+		// scheduled and archived posts keep their state unless post_status
+		// asks for another one.
+		status = current.Status
+		if current.Status == post.StatusDraft || current.Status == post.StatusPublished {
+			status = post.StatusDraft
+			if params.bool(4, true) {
+				status = post.StatusPublished
+			}
+		}
+	}
+
+	status, publishedAt := content.schedule(status)
+	if status != current.Status || (publishedAt != nil && !equalTime(publishedAt, current.PublishedAt)) {
+		dto.Status, dto.PublishedAt = &status, publishedAt
+	}
+
+	if _, apiErr := s.postService.Update(ctx, id, current.Version, dto); apiErr != nil {
+		return nil, apiErr
+	}
+	return true, nil
+}
+
+// metaWeblog.getPost(postid, username, password)
+func (s *XMLRPCService) getPost(ctx context.Context, params args) (any, *errors.ApiError) {
+	id, apiErr := params.string(0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	ctx, apiErr = s.login(ctx, params, 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	found, apiErr := s.postService.FindById(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return s.encodePost(found), nil
+}
+
+// metaWeblog.getRecentPosts(blogid, username, password, numberOfPosts)
+func (s *XMLRPCService) getRecentPosts(ctx context.Context, params args) (any, *errors.ApiError) {
+	ctx, apiErr := s.login(ctx, params, 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	limit, apiErr := params.int(3)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	limit = min(max(limit, 1), maxRecentPosts)
+
+	actor, _ := user.FromContext(ctx)
+	posts, _, apiErr := s.postService.FindAllByAuthor(ctx, actor.Id, true, limit, nil)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	encoded := make([]any, len(posts))
+	for i, p := range posts {
+		encoded[i] = s.encodePost(p)
+	}
+	return encoded, nil
+}
+
+// metaWeblog.getCategories(blogid, username, password) has nothing to list:
+// posts are tagged freely through categories instead.
+func (s *XMLRPCService) getCategories(ctx context.Context, params args) (any, *errors.ApiError) {
+	if _, apiErr := s.login(ctx, params, 1); apiErr != nil {
+		return nil, apiErr
+	}
+	return []any{}, nil
+}
+
+// metaWeblog.newMediaObject(blogid, username, password, struct{name, type, bits})
+func (s *XMLRPCService) newMediaObject(ctx context.Context, params args) (any, *errors.ApiError) {
+	ctx, apiErr := s.login(ctx, params, 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if _, apiErr := user.Authorize(ctx, user.User.CanWritePosts); apiErr != nil {
+		return nil, apiErr
+	}
+
+	object, apiErr := params.content(3)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	bits, ok := object["bits"].([]byte)
+	name := object.string("name")
+	if !ok || name == "" {
+		return nil, errors.NewApiError(http.StatusBadRequest, "name and bits are required")
+	}
+
+	url, err := s.media.Save(name, bits)
+	switch {
+	case stderrors.Is(err, media.ErrUnsupportedType):
+		return nil, errors.NewApiError(http.StatusUnsupportedMediaType, err.Error())
+	case stderrors.Is(err, media.ErrTooLarge):
+		return nil, errors.NewApiError(http.StatusRequestEntityTooLarge, err.Error())
+	case err != nil:
+		return nil, errors.NewApiError(http.StatusInternalServerError, err.Error())
+	}
+
+	return map[string]any{"url": url}, nil
+}
+
+// login checks the username (the user's email) and password found at
+// params[at] and params[at+1] and returns a context carrying the user.
+func (s *XMLRPCService) login(ctx context.Context, params args, at int) (context.Context, *errors.ApiError) {
+	email, apiErr := params.string(at)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	password, apiErr := params.string(at + 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	found, apiErr := s.userService.Authenticate(ctx, email, password)
+	if apiErr != nil {
+		return nil, errors.NewApiError(http.StatusForbidden, "incorrect username or password")
+	}
+
+	return user.NewContext(ctx, found), nil
+}
+
+func (s *XMLRPCService) encodePost(p post.Post) map[string]any {
+	created := p.CreatedAt
+	if p.PublishedAt != nil {
+		created = *p.PublishedAt
+	}
+
+	categories := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		categories[i] = tag.Name
+	}
+
+	link := s.siteURL + "/posts/" + p.Slug
+	return map[string]any{
+		"postid":      p.ID,
+		"userid":      p.AuthorId,
+		"title":       p.Title,
+		"description": p.Content,
+		"link":        link,
+		"permaLink":   link,
+		"categories":  categories,
+		"dateCreated": created,
+		"wp_slug":     p.Slug,
+		"post_status": postStatuses[p.Status],
+	}
+}
+
+type args []any
+
+func (a args) string(i int) (string, *errors.ApiError) {
+	if i >= len(a) {
+		return "", errors.NewApiError(http.StatusBadRequest, fmt.Sprintf("missing parameter %d", i+1))
+	}
+
+	switch v := a[i].(type) {
+	case string:
+		return v, nil
+	case int:
+		return fmt.Sprint(v), nil
+	}
+	return "", errors.NewApiError(http.StatusBadRequest, fmt.Sprintf("parameter %d must be a string", i+1))
+}
+
+func (a args) int(i int) (int, *errors.ApiError) {
+	if i >= len(a) {
+		return 0, errors.NewApiError(http.StatusBadRequest, fmt.Sprintf("missing parameter %d", i+1))
+	}
+
+	v, ok := a[i].(int)
+	if !ok {
+		return 0, errors.NewApiError(http.StatusBadRequest, fmt.Sprintf("parameter %d must be an int", i+1))
+	}
+	return v, nil
+}
+
+func (a args) bool(i int, fallback bool) bool {
+	if i >= len(a) {
+		return fallback
+	}
+	v, ok := a[i].(bool)
+	if !ok {
+		return fallback
+	}
+	return v
+}
+
+func (a args) content(i int) (content, *errors.ApiError) {
+	if i >= len(a) {
+		return nil, errors.NewApiError(http.StatusBadRequest, fmt.Sprintf("missing parameter %d", i+1))
+	}
+
+	v, ok := a[i].(map[string]any)
+	if !ok {
+		return nil, errors.NewApiError(http.StatusBadRequest, fmt.Sprintf("parameter %d must be a struct", i+1))
+	}
+	return content(v), nil
+}
+
+type content map[string]any
+
+func (c content) has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
+
+func (c content) string(name string) string {
+	v, _ := c[name].(string)
+	return strings.TrimSpace(v)
+}
+
+// tags merges categories with the comma-separated mt_keywords that
+// MovableType-style editors send.
+func (c content) tags() []string {
+	tags := []string{}
+	if categories, ok := c["categories"].([]any); ok {
+		for _, category := range categories {
+			if name, ok := category.(string); ok && strings.TrimSpace(name) != "" {
+				tags = append(tags, strings.TrimSpace(name))
+			}
+		}
+	}
+	for _, keyword := range strings.Split(c.string("mt_keywords"), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			tags = append(tags, keyword)
+		}
+	}
+	return tags
+}
+
+// postStatuses maps post states to the WordPress post_status values clients
+// read from getPost and send back on edits.
+var postStatuses = map[post.Status]string{
+	post.StatusDraft:     "draft",
+	post.StatusPublished: "publish",
+	post.StatusScheduled: "future",
+	post.StatusArchived:  "archived",
+}
+
+// status returns the state named by post_status, or "" when it is missing.
+func (c content) status() (post.Status, bool) {
+	name := c.string("post_status")
+	if name == "" {
+		return "", true
+	}
+	for status, value := range postStatuses {
+		if value == name {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// schedule applies dateCreated to a post that is being published: a future
+// date schedules it and a past one backdates it. Other states ignore it.
+func (c content) schedule(status post.Status) (post.Status, *time.Time) {
+	created, ok := c["dateCreated"].(time.Time)
+	if !ok || (status != post.StatusPublished && status != post.StatusScheduled) {
+		return status, nil
+	}
+	if created.After(time.Now()) {
+		return post.StatusScheduled, &created
+	}
+	return post.StatusPublished, &created
+}
+
+// equalTime compares to the second, the precision of XML-RPC dates, so a
+// dateCreated echoed back from getPost is not taken as a change.
+func equalTime(a, b *time.Time) bool {
+	return b != nil && a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -10,6 +12,27 @@ type Config struct {
 	DSN       string
 	Port      string
 	JWTSecret string
+	JWTKeys   string
+
+	MigrateOnStart bool
+	SearchLanguage string
+
+	ModerationFirstTime     bool
+	ModerationMaxLinks      int
+	ModerationBannedWords   []string
+	ModerationSpamThreshold float64
+
+	ReactionTypes   []string
+	FeedFanoutLimit int
+
+	SiteURL     string
+	SiteTitle   string
+	FeedSummary bool
+
+	RobotsFile     string
+	RobotsDisallow []string
+
+	MediaDir string
 }
 
 func Load() Config {
@@ -17,8 +40,82 @@ func Load() Config {
 	dsn := os.Getenv("DATABASE_URL")
 	port := os.Getenv("PORT")
 	jwtSecret := os.Getenv("JWT_SECRET")
+	jwtKeys := os.Getenv("JWT_KEYS")
+	migrateOnStart, _ := strconv.ParseBool(os.Getenv("MIGRATE_ON_START"))
+	searchLanguage := os.Getenv("SEARCH_LANGUAGE")
+	moderationFirstTime, err := strconv.ParseBool(os.Getenv("MODERATION_FIRST_TIME"))
+	if err != nil {
+		moderationFirstTime = true
+	}
+	moderationMaxLinks, err := strconv.Atoi(os.Getenv("MODERATION_MAX_LINKS"))
+	if err != nil {
+		moderationMaxLinks = 2
+	}
+	moderationSpamThreshold, err := strconv.ParseFloat(os.Getenv("MODERATION_SPAM_THRESHOLD"), 64)
+	if err != nil {
+		moderationSpamThreshold = 0.9
+	}
+	moderationBannedWords := list(strings.ToLower(os.Getenv("MODERATION_BANNED_WORDS")))
+	reactionTypes := list(strings.ToLower(os.Getenv("REACTION_TYPES")))
+	if len(reactionTypes) == 0 {
+		reactionTypes = []string{"like", "clap", "heart"}
+	}
+	feedFanoutLimit, err := strconv.Atoi(os.Getenv("FEED_FANOUT_LIMIT"))
+	if err != nil {
+		feedFanoutLimit = 10000
+	}
+	siteTitle := os.Getenv("SITE_TITLE")
+	if siteTitle == "" {
+		siteTitle = "Blog"
+	}
+	feedSummary := os.Getenv("FEED_CONTENT") == "summary"
 	if port == "" {
 		port = "8080"
 	}
-	return Config{DSN: dsn, Port: port, JWTSecret: jwtSecret}
+	siteURL := os.Getenv("SITE_URL")
+	if siteURL == "" {
+		siteURL = "http://localhost:" + port
+	}
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	if searchLanguage == "" {
+		searchLanguage = "english"
+	}
+	return Config{
+		DSN:            dsn,
+		Port:           port,
+		JWTSecret:      jwtSecret,
+		JWTKeys:        jwtKeys,
+		MigrateOnStart: migrateOnStart,
+		SearchLanguage: searchLanguage,
+
+		ModerationFirstTime:     moderationFirstTime,
+		ModerationMaxLinks:      moderationMaxLinks,
+		ModerationBannedWords:   moderationBannedWords,
+		ModerationSpamThreshold: moderationSpamThreshold,
+
+		ReactionTypes:   reactionTypes,
+		FeedFanoutLimit: feedFanoutLimit,
+
+		SiteURL:     siteURL,
+		SiteTitle:   siteTitle,
+		FeedSummary: feedSummary,
+
+		RobotsFile:     os.Getenv("ROBOTS_FILE"),
+		RobotsDisallow: list(os.Getenv("ROBOTS_DISALLOW")),
+
+		MediaDir: mediaDir,
+	}
+}
+
+func list(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
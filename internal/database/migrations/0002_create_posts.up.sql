@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS posts (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title      TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    author_id  UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS posts_created_at_idx ON posts (created_at DESC);
+CREATE INDEX IF NOT EXISTS posts_author_id_created_at_idx ON posts (author_id, created_at DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE posts
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN version    INTEGER NOT NULL DEFAULT 1;
+
+UPDATE posts SET updated_at = created_at;
@@ -0,0 +1,14 @@
+CREATE TABLE post_revisions (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id    UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    version    INTEGER NOT NULL,
+    title      TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    editor_id  UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (post_id, version)
+);
+
+INSERT INTO post_revisions (post_id, version, title, content, editor_id, created_at)
+SELECT id, version, title, content, author_id, updated_at
+FROM posts;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS posts_scheduled_idx;
+DROP INDEX IF EXISTS posts_status_created_at_idx;
+
+ALTER TABLE posts
+    DROP CONSTRAINT IF EXISTS posts_scheduled_published_at_check,
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+ALTER TABLE posts
+    ADD COLUMN status       TEXT NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'scheduled', 'published', 'archived')),
+    ADD COLUMN published_at TIMESTAMPTZ;
+
+UPDATE posts SET published_at = created_at;
+
+ALTER TABLE posts
+    ADD CONSTRAINT posts_scheduled_published_at_check
+        CHECK (status <> 'scheduled' OR published_at IS NOT NULL);
+
+CREATE INDEX posts_status_created_at_idx ON posts (status, created_at DESC);
+CREATE INDEX posts_scheduled_idx ON posts (published_at) WHERE status = 'scheduled';
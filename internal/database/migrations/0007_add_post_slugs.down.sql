@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_slugs;
+DROP INDEX IF EXISTS posts_slug_idx;
+ALTER TABLE posts DROP COLUMN IF EXISTS slug;
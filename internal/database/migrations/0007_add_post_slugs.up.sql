@@ -0,0 +1,18 @@
+ALTER TABLE posts ADD COLUMN slug TEXT;
+
+UPDATE posts
+SET slug = COALESCE(NULLIF(trim(BOTH '-' FROM regexp_replace(lower(title), '[^a-z0-9]+', '-', 'g')), ''), 'post')
+    || '-' || left(id::text, 8);
+
+CREATE UNIQUE INDEX posts_slug_idx ON posts (slug);
+
+CREATE TABLE post_slugs (
+    slug       TEXT PRIMARY KEY,
+    post_id    UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX post_slugs_post_id_idx ON post_slugs (post_id);
+
+INSERT INTO post_slugs (slug, post_id)
+SELECT slug, id FROM posts;
@@ -0,0 +1,31 @@
+CREATE TABLE tags (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         TEXT NOT NULL,
+    slug         TEXT NOT NULL UNIQUE,
+    canonical_id UUID REFERENCES tags (id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (canonical_id IS NULL OR canonical_id <> id)
+);
+
+CREATE TABLE post_tags (
+    post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    tag_id  UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE INDEX post_tags_tag_id_idx ON post_tags (tag_id);
+
+CREATE TABLE categories (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    slug       TEXT NOT NULL UNIQUE,
+    parent_id  UUID REFERENCES categories (id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+ALTER TABLE posts ADD COLUMN category_id UUID REFERENCES categories (id) ON DELETE SET NULL;
+
+CREATE INDEX posts_category_id_idx ON posts (category_id);
@@ -0,0 +1,10 @@
+ALTER TABLE posts
+    ADD COLUMN language regconfig NOT NULL DEFAULT 'english';
+
+ALTER TABLE posts
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector(language, coalesce(title, '')), 'A') ||
+        setweight(to_tsvector(language, coalesce(content, '')), 'B')
+    ) STORED;
+
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
@@ -0,0 +1,2 @@
+CREATE INDEX posts_created_at_id_idx ON posts (created_at DESC, id DESC) WHERE status = 'published';
+CREATE INDEX posts_author_id_created_at_id_idx ON posts (author_id, created_at DESC, id DESC);
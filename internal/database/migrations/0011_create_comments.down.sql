@@ -0,0 +1,5 @@
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS comment_count,
+    DROP COLUMN IF EXISTS comments_locked;
+
+DROP TABLE IF EXISTS comments;
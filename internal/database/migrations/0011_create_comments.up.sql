@@ -0,0 +1,23 @@
+CREATE TABLE comments (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seq        BIGSERIAL NOT NULL UNIQUE,
+    post_id    UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    parent_id  UUID REFERENCES comments (id) ON DELETE CASCADE,
+    root_id    UUID NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    author_id  UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    path       TEXT COLLATE "C" NOT NULL,
+    depth      INT NOT NULL DEFAULT 0,
+    content    TEXT NOT NULL,
+    edited_at  TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX comments_root_id_idx ON comments (root_id);
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
+CREATE INDEX comments_post_created_at_idx ON comments (post_id, created_at DESC, id DESC);
+CREATE INDEX comments_roots_idx ON comments (post_id, created_at DESC, id DESC) WHERE parent_id IS NULL;
+
+ALTER TABLE posts
+    ADD COLUMN comments_locked BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN comment_count   INT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS spam_corpus;
+DROP TABLE IF EXISTS spam_tokens;
+DROP TABLE IF EXISTS moderation_decisions;
+
+DROP INDEX IF EXISTS comments_moderation_pending_idx;
+DROP INDEX IF EXISTS posts_moderation_pending_idx;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS spam_score,
+    DROP COLUMN IF EXISTS moderation_reasons,
+    DROP COLUMN IF EXISTS moderation_status;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS spam_score,
+    DROP COLUMN IF EXISTS moderation_reasons,
+    DROP COLUMN IF EXISTS moderation_status;
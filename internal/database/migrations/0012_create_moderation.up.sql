@@ -0,0 +1,40 @@
+ALTER TABLE posts
+    ADD COLUMN moderation_status  TEXT NOT NULL DEFAULT 'approved'
+        CHECK (moderation_status IN ('pending', 'approved', 'rejected', 'spam')),
+    ADD COLUMN moderation_reasons TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN spam_score         DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE comments
+    ADD COLUMN moderation_status  TEXT NOT NULL DEFAULT 'approved'
+        CHECK (moderation_status IN ('pending', 'approved', 'rejected', 'spam')),
+    ADD COLUMN moderation_reasons TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN spam_score         DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE INDEX posts_moderation_pending_idx ON posts (created_at DESC, id DESC) WHERE moderation_status = 'pending';
+CREATE INDEX comments_moderation_pending_idx ON comments (created_at DESC, id DESC) WHERE moderation_status = 'pending';
+
+CREATE TABLE moderation_decisions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_type    TEXT NOT NULL CHECK (item_type IN ('post', 'comment')),
+    item_id      UUID NOT NULL,
+    moderator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    decision     TEXT NOT NULL CHECK (decision IN ('approve', 'reject', 'spam')),
+    spam_score   DOUBLE PRECISION NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX moderation_decisions_item_idx ON moderation_decisions (item_type, item_id);
+
+CREATE TABLE spam_tokens (
+    token      TEXT PRIMARY KEY,
+    spam_count INT NOT NULL DEFAULT 0,
+    ham_count  INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE spam_corpus (
+    id        BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    spam_docs INT NOT NULL DEFAULT 0,
+    ham_docs  INT NOT NULL DEFAULT 0
+);
+
+INSERT INTO spam_corpus DEFAULT VALUES;
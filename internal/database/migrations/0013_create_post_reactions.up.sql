@@ -0,0 +1,11 @@
+CREATE TABLE post_reactions (
+    post_id    UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (post_id, user_id, type)
+);
+
+CREATE INDEX post_reactions_user_id_idx ON post_reactions (user_id, post_id);
+
+ALTER TABLE posts ADD COLUMN reaction_counts JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS feed_items;
+
+ALTER TABLE users DROP COLUMN IF EXISTS follower_count;
+
+DROP TABLE IF EXISTS follows;
@@ -0,0 +1,21 @@
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id, created_at DESC);
+
+ALTER TABLE users ADD COLUMN follower_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE feed_items (
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id    UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    author_id  UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX feed_items_user_author_idx ON feed_items (user_id, author_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ap_deliveries;
+DROP TABLE IF EXISTS ap_followers;
+DROP TABLE IF EXISTS ap_actor_keys;
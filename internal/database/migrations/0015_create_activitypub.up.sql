@@ -0,0 +1,28 @@
+CREATE TABLE ap_actor_keys (
+    user_id         UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    public_key_pem  TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE ap_followers (
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    actor_uri    TEXT NOT NULL,
+    inbox        TEXT NOT NULL,
+    shared_inbox TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, actor_uri)
+);
+
+CREATE TABLE ap_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    inbox           TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ap_deliveries_next_attempt_at_idx ON ap_deliveries (next_attempt_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webmention_sends;
+DROP TABLE IF EXISTS webmentions;
@@ -0,0 +1,37 @@
+CREATE TABLE webmentions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id         UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    source          TEXT NOT NULL,
+    target          TEXT NOT NULL,
+    type            TEXT NOT NULL DEFAULT 'mention',
+    status          TEXT NOT NULL DEFAULT 'unverified',
+    title           TEXT NOT NULL DEFAULT '',
+    author_name     TEXT NOT NULL DEFAULT '',
+    author_url      TEXT NOT NULL DEFAULT '',
+    content         TEXT NOT NULL DEFAULT '',
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT,
+    verified_at     TIMESTAMPTZ,
+    approved_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (source, target)
+);
+
+CREATE INDEX webmentions_unverified_idx ON webmentions (next_attempt_at) WHERE status = 'unverified';
+CREATE INDEX webmentions_post_created_at_idx ON webmentions (post_id, created_at DESC, id DESC);
+CREATE INDEX webmentions_status_created_at_idx ON webmentions (status, created_at DESC, id DESC);
+
+CREATE TABLE webmention_sends (
+    id              BIGSERIAL PRIMARY KEY,
+    source          TEXT NOT NULL,
+    target          TEXT NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (source, target)
+);
+
+CREATE INDEX webmention_sends_next_attempt_at_idx ON webmention_sends (next_attempt_at);
@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS render_version,
+    DROP COLUMN IF EXISTS content_html;
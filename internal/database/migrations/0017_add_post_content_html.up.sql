@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    ADD COLUMN content_html   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN render_version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+ALTER TABLE posts
+    ADD COLUMN word_count   INT NOT NULL DEFAULT 0,
+    ADD COLUMN reading_time INT NOT NULL DEFAULT 0,
+    ADD COLUMN excerpt      TEXT NOT NULL DEFAULT '';
+
+-- Rough values from the raw markdown; each post gets exact ones the next time
+-- it is rendered.
+UPDATE posts
+SET word_count = CASE WHEN btrim(content) = '' THEN 0 ELSE cardinality(regexp_split_to_array(btrim(content), '\s+')) END,
+    excerpt = left(btrim(regexp_replace(regexp_replace(split_part(content, '<!--more-->', 1), '[#*_`>\[\]]', '', 'g'), '\s+', ' ', 'g')), 280);
+
+UPDATE posts
+SET reading_time = (word_count + 199) / 200;
@@ -0,0 +1,6 @@
+-- Feeds cannot date a post that left them, so the time of the last removal is
+-- kept here to move Last-Modified forward.
+CREATE TABLE syndication_state (
+    id         BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    removed_at TIMESTAMPTZ NOT NULL
+);
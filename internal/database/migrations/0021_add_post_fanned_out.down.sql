@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS feed_items_user_created_at_idx;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS fanned_out;
@@ -0,0 +1,15 @@
+ALTER TABLE posts ADD COLUMN fanned_out BOOLEAN NOT NULL DEFAULT false;
+
+-- Feeds used to merge posts by their author's current follower count, so
+-- published posts had no record of how they were delivered. Fan them all out
+-- to keep them in the feeds they already appear in.
+INSERT INTO feed_items (user_id, post_id, author_id, created_at)
+SELECT f.follower_id, p.id, p.author_id, p.created_at
+FROM follows f
+JOIN posts p ON p.author_id = f.followee_id
+WHERE p.status = 'published'
+ON CONFLICT DO NOTHING;
+
+UPDATE posts SET fanned_out = true WHERE status = 'published';
+
+CREATE INDEX feed_items_user_created_at_idx ON feed_items (user_id, created_at DESC, post_id DESC);
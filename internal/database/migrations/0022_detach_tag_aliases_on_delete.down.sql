@@ -0,0 +1,3 @@
+ALTER TABLE tags
+    DROP CONSTRAINT tags_canonical_id_fkey,
+    ADD CONSTRAINT tags_canonical_id_fkey FOREIGN KEY (canonical_id) REFERENCES tags (id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Deleting a canonical tag keeps its aliases, and their posts, as plain tags.
+ALTER TABLE tags
+    DROP CONSTRAINT tags_canonical_id_fkey,
+    ADD CONSTRAINT tags_canonical_id_fkey FOREIGN KEY (canonical_id) REFERENCES tags (id) ON DELETE SET NULL;
@@ -1,15 +1,58 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-
-	"github.com/joaopdias/blog-server/internal/config"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSecret = []byte(config.Load().JWTSecret)
+const (
+	Issuer          = "blog-server"
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	ScopedTokenTTL  = 90 * 24 * time.Hour
+)
+
+// Scopes a token can be limited to, named after the IndieAuth scopes that
+// Micropub clients request.
+var Scopes = []string{"create", "update", "delete", "draft", "media"}
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// HasScope reports whether the token grants scope. Tokens issued at login
+// carry no scope and grant everything.
+func (c *Claims) HasScope(scope string) bool {
+	return c.Scope == "" || slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+type RevocationStore interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+var (
+	keys        *KeySet
+	revocations RevocationStore
+)
+
+func UseKeySet(keySet *KeySet) {
+	keys = keySet
+}
+
+func UseRevocationStore(store RevocationStore) {
+	revocations = store
+}
 
 func HashPassword(password string) string {
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -24,33 +67,113 @@ func CheckPasswordHash(password, hashed string) bool {
 	return err == nil
 }
 
-func GenerateJWT(payload string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub": payload,
+func GenerateJWT(subject string) (string, *Claims, error) {
+	return generateJWT(subject, "", AccessTokenTTL)
+}
+
+// GenerateScopedJWT issues a long-lived token limited to scope, for clients
+// such as Micropub editors that cannot refresh.
+func GenerateScopedJWT(subject, scope string) (string, *Claims, error) {
+	return generateJWT(subject, scope, ScopedTokenTTL)
+}
+
+func generateJWT(subject, scope string, ttl time.Duration) (string, *Claims, error) {
+	if keys == nil {
+		return "", nil, fmt.Errorf("no signing key configured")
+	}
+
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    Issuer,
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Scope: scope,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key := keys.Signing()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Id
 
-	return token.SignedString(jwtSecret)
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return signed, claims, nil
 }
 
-func ParseJWT(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+func ParseJWT(ctx context.Context, tokenString string) (*Claims, error) {
+	if keys == nil {
+		return nil, fmt.Errorf("no verification keys configured")
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return jwtSecret, nil
-	})
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods(keys.Methods()), jwt.WithIssuer(Issuer), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("sub claim not found")
+	}
+
+	if claims.ID == "" {
+		return nil, fmt.Errorf("jti claim not found")
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if sub, ok := claims["sub"].(string); ok {
-			return sub, nil
+	if revocations != nil {
+		revoked, err := revocations.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, fmt.Errorf("token revoked")
 		}
-		return "", fmt.Errorf("sub claim not found")
 	}
 
-	return "", fmt.Errorf("invalid token")
+	return claims, nil
+}
+
+func GenerateRefreshToken() (string, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minHMACKeySize = 32
+
+type Key struct {
+	Id        string
+	Method    jwt.SigningMethod
+	signKey   crypto.PrivateKey
+	verifyKey crypto.PublicKey
+}
+
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeySet parses a comma-separated list of kid:alg:path entries. The first
+// entry signs new tokens; the rest are only accepted for verification so that
+// tokens issued before a rotation stay valid until they expire. When no list is
+// configured the legacy shared secret is used as a single HS256 key.
+func LoadKeySet(spec, legacySecret string) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*Key{}}
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		if legacySecret == "" {
+			return nil, fmt.Errorf("no signing key configured: set JWT_KEYS or JWT_SECRET")
+		}
+		key, err := newHMACKey("default", []byte(legacySecret))
+		if err != nil {
+			return nil, err
+		}
+		ks.add(key)
+		return ks, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid key entry %q: expected kid:alg:path", entry)
+		}
+
+		key, err := loadKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := ks.keys[key.Id]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.Id)
+		}
+		ks.add(key)
+	}
+
+	if ks.signing == nil {
+		return nil, fmt.Errorf("no signing key configured")
+	}
+
+	if !ks.signing.CanSign() {
+		return nil, fmt.Errorf("key %q cannot sign: a private key is required", ks.signing.Id)
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) Signing() *Key {
+	return ks.signing
+}
+
+func (ks *KeySet) Lookup(kid string) (*Key, bool) {
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range ks.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range ks.ordered() {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Kid: key.Id,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Kid: key.Id,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	return jwks
+}
+
+func (ks *KeySet) add(key *Key) {
+	if ks.signing == nil {
+		ks.signing = key
+	}
+	ks.keys[key.Id] = key
+}
+
+func (ks *KeySet) ordered() []*Key {
+	keys := []*Key{ks.signing}
+	for id, key := range ks.keys {
+		if id != ks.signing.Id {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func loadKey(kid, alg, path string) (*Key, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("key id must not be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %q: %w", kid, err)
+	}
+
+	switch alg {
+	case "HS256":
+		return newHMACKey(kid, []byte(strings.TrimRight(string(data), "\r\n")))
+	case "RS256":
+		return newRSAKey(kid, data)
+	case "EdDSA":
+		return newEd25519Key(kid, data)
+	}
+
+	return nil, fmt.Errorf("key %q: unsupported algorithm %q", kid, alg)
+}
+
+func newHMACKey(kid string, secret []byte) (*Key, error) {
+	if len(secret) < minHMACKeySize {
+		return nil, fmt.Errorf("key %q: HS256 secret must be at least %d bytes", kid, minHMACKeySize)
+	}
+	return &Key{Id: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+func newRSAKey(kid string, data []byte) (*Key, error) {
+	parsed, err := parsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{Id: kid, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{Id: kid, Method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	}
+
+	return nil, fmt.Errorf("key %q: not an RSA key", kid)
+}
+
+func newEd25519Key(kid string, data []byte) (*Key, error) {
+	parsed, err := parsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		return &Key{Id: kid, Method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{Id: kid, Method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	}
+
+	return nil, fmt.Errorf("key %q: not an Ed25519 key", kid)
+}
+
+func parsePEM(data []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
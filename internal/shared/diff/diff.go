@@ -0,0 +1,143 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+type Chunk struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+func Lines(a, b string) []Chunk {
+	return compute(splitLines(a), splitLines(b))
+}
+
+func Words(a, b string) []Chunk {
+	return compute(splitWords(a), splitWords(b))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.SplitAfter(s, "\n")
+}
+
+func splitWords(s string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range s {
+		if i == start {
+			continue
+		}
+		prev, _ := utf8.DecodeLastRuneInString(s[start:i])
+		if unicode.IsSpace(prev) != unicode.IsSpace(r) {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// compute runs Myers' O((N+M)D) algorithm, keeping only the active diagonal
+// window of each round so memory grows with the edit distance rather than the
+// input size.
+func compute(a, b []string) []Chunk {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	return nil
+}
+
+func backtrack(trace [][]int, a, b []string) []Chunk {
+	x, y := len(a), len(b)
+	var reversed []Chunk
+
+	for d := len(trace) - 1; d > 0; d-- {
+		window := trace[d]
+		at := func(k int) int { return window[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Chunk{Op: OpEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			reversed = append(reversed, Chunk{Op: OpInsert, Text: b[y-1]})
+		} else {
+			reversed = append(reversed, Chunk{Op: OpDelete, Text: a[x-1]})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Chunk{Op: OpEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	chunks := make([]Chunk, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		c := reversed[i]
+		if last := len(chunks) - 1; last >= 0 && chunks[last].Op == c.Op {
+			chunks[last].Text += c.Text
+			continue
+		}
+		chunks = append(chunks, c)
+	}
+
+	return chunks
+}
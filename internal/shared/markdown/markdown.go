@@ -0,0 +1,179 @@
+package markdown
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gosimple/slug"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// Version identifies the output of Render. Bump it whenever the pipeline
+// changes so HTML cached with older posts is rendered again.
+const Version = 5
+
+const style = "github"
+
+var (
+	converter = goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(style),
+				highlighting.WithGuessLanguage(true),
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+	policy = newPolicy()
+)
+
+type Heading struct {
+	Id       string    `json:"id"`
+	Text     string    `json:"text"`
+	Level    int       `json:"level"`
+	Children []Heading `json:"children,omitempty"`
+}
+
+type Document struct {
+	HTML        string
+	TOC         []Heading
+	WordCount   int
+	ReadingTime int
+	Excerpt     string
+}
+
+// Render converts CommonMark with the GFM extensions (tables, task lists,
+// strikethrough, autolinks) and footnotes to HTML. Fenced code is highlighted
+// with classes styled by Stylesheet, guessing the language when none is given,
+// and headings get ids that the table of contents links to. Raw HTML in the
+// source is kept, so the result is always passed through the sanitizer.
+// Reading stats and an excerpt are derived from the same parse.
+func Render(source string) (Document, error) {
+	src := []byte(source)
+	doc := converter.Parser().Parse(text.NewReader(src), parser.WithContext(parser.NewContext(parser.WithIDs(headingIDs{}))))
+
+	var out bytes.Buffer
+	if err := converter.Renderer().Render(&out, src, doc); err != nil {
+		return Document{}, err
+	}
+
+	document := Document{HTML: policy.Sanitize(out.String()), TOC: toc(doc, src)}
+	summarize(&document, doc, src)
+
+	return document, nil
+}
+
+// Stylesheet writes the CSS for the classes used in highlighted code.
+func Stylesheet(w io.Writer) error {
+	return chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(w, styles.Get(style))
+}
+
+// toc nests each heading under the closest preceding heading of a higher level.
+func toc(doc ast.Node, source []byte) []Heading {
+	root := Heading{Children: []Heading{}}
+	stack := []*Heading{&root}
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+
+		for len(stack) > 1 && stack[len(stack)-1].Level >= heading.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, Heading{
+			Id:    string(idBytes),
+			Text:  plainText(heading, source),
+			Level: heading.Level,
+		})
+		stack = append(stack, &parent.Children[len(parent.Children)-1])
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return root.Children
+}
+
+// headingIDs transliterates heading text into anchor ids the way post slugs
+// are made, so "Café" becomes "cafe" rather than losing the accented letter.
+type headingIDs map[string]bool
+
+func (ids headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := slug.Make(string(value))
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for i := 1; ids[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	ids[id] = true
+	return []byte(id)
+}
+
+func (ids headingIDs) Put(value []byte) {
+	ids[string(value)] = true
+}
+
+func plainText(n ast.Node, source []byte) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.String()
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(false)
+
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^fn(ref\d*)?:\d+$`)).OnElements("li", "sup", "a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^(footnotes|footnote-ref|footnote-backref)$`)).OnElements("div", "a", "section")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^(doc-noteref|doc-backlink|doc-endnotes)$`)).OnElements("a", "div", "section")
+
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-z0-9-]+( [a-z0-9-]+)*$`)).OnElements("pre", "code", "span")
+
+	p.AllowStyles("text-align").MatchingEnum("left", "center", "right").OnElements("th", "td")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+
+	return p
+}
@@ -0,0 +1,113 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	moreMarker = "<!--more-->"
+
+	wordsPerMinute = 200
+	excerptLength  = 280
+	maxExcerpt     = 1000
+)
+
+// summarize fills the word count, reading time and excerpt of a document.
+// Authors can pick the excerpt by placing moreMarker in the source, otherwise
+// it is cut from the opening paragraphs at a sentence boundary.
+func summarize(document *Document, doc ast.Node, source []byte) {
+	prose, words := plain(doc, source)
+
+	document.WordCount = words
+	document.ReadingTime = (words + wordsPerMinute - 1) / wordsPerMinute
+
+	if head, _, found := strings.Cut(string(source), moreMarker); found {
+		intro, _ := plain(converter.Parser().Parse(text.NewReader([]byte(head))), []byte(head))
+		document.Excerpt = truncate(intro, maxExcerpt)
+		return
+	}
+
+	document.Excerpt = excerpt(prose, excerptLength)
+}
+
+// plain returns the text of the paragraphs of a document, without markup, and
+// the number of words in it, counting headings, table cells, footnotes and
+// code as well.
+func plain(doc ast.Node, source []byte) (string, int) {
+	var paragraphs []string
+	words := 0
+	footnotes := false
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n.Kind() == east.KindFootnoteList {
+			footnotes = entering
+			return ast.WalkContinue, nil
+		}
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n.Kind() {
+		case ast.KindParagraph, ast.KindTextBlock:
+			text := strings.Join(strings.Fields(plainText(n, source)), " ")
+			if text != "" {
+				words += len(strings.Fields(text))
+				if !footnotes {
+					paragraphs = append(paragraphs, text)
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case ast.KindHeading, east.KindTableCell:
+			words += len(strings.Fields(plainText(n, source)))
+			return ast.WalkSkipChildren, nil
+		case ast.KindCodeBlock, ast.KindFencedCodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				line := lines.At(i)
+				words += len(strings.Fields(string(line.Value(source))))
+			}
+			return ast.WalkSkipChildren, nil
+		case ast.KindHTMLBlock:
+			return ast.WalkSkipChildren, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return strings.Join(paragraphs, " "), words
+}
+
+// excerpt keeps the sentences that fit in limit runes. When even the first
+// sentence is longer, it falls back to cutting at a word boundary.
+func excerpt(prose string, limit int) string {
+	if utf8.RuneCountInString(prose) <= limit {
+		return prose
+	}
+
+	runes := []rune(prose)
+	for i := limit - 1; i > 0; i-- {
+		if strings.ContainsRune(".!?…", runes[i]) && unicode.IsSpace(runes[i+1]) {
+			return string(runes[:i+1])
+		}
+	}
+
+	return truncate(prose, limit)
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	cut := string([]rune(s)[:limit])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
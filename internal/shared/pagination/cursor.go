@@ -0,0 +1,112 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Direction string
+
+const (
+	Forward  Direction = "next"
+	Backward Direction = "prev"
+)
+
+type Cursor struct {
+	CreatedAt time.Time
+	Id        string
+	Direction Direction
+}
+
+type Page struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+type payload struct {
+	T int64  `json:"t"`
+	I string `json:"i"`
+	D string `json:"d"`
+}
+
+func Encode(c Cursor) string {
+	data, _ := json.Marshal(payload{T: c.CreatedAt.UnixMicro(), I: c.Id, D: string(c.Direction)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func Decode(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil || p.I == "" {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	direction := Direction(p.D)
+	if direction != Forward && direction != Backward {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return Cursor{CreatedAt: time.UnixMicro(p.T).UTC(), Id: p.I, Direction: direction}, nil
+}
+
+// Paginate trims the extra row a repository fetched to detect another page,
+// restores newest-first order for backward pages and builds the cursors.
+func Paginate[T any](items []T, limit int, cursor *Cursor, key func(T) (time.Time, string)) ([]T, Page) {
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	backward := cursor != nil && cursor.Direction == Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	var page Page
+	if len(items) == 0 {
+		return items, page
+	}
+
+	first, firstId := key(items[0])
+	last, lastId := key(items[len(items)-1])
+
+	if hasMore || backward {
+		page.Next = Encode(Cursor{CreatedAt: last, Id: lastId, Direction: Forward})
+	}
+	if cursor != nil && (!backward || hasMore) {
+		page.Prev = Encode(Cursor{CreatedAt: first, Id: firstId, Direction: Backward})
+	}
+
+	return items, page
+}
+
+func LinkHeader(base *url.URL, page Page) string {
+	var links []string
+	for _, link := range []struct {
+		rel    string
+		cursor string
+	}{{"next", page.Next}, {"prev", page.Prev}} {
+		if link.cursor == "" {
+			continue
+		}
+
+		u := *base
+		query := u.Query()
+		query.Set("cursor", link.cursor)
+		query.Del("offset")
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), link.rel))
+	}
+
+	return strings.Join(links, ", ")
+}
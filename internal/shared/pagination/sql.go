@@ -0,0 +1,27 @@
+package pagination
+
+import "fmt"
+
+// Keyset renders the condition, ordering and limit for a (created_at, id)
+// keyset page. Placeholders start at $first; one extra row is requested so
+// Paginate can tell whether another page exists.
+func Keyset(cursor *Cursor, alias string, first, limit int) (string, []any) {
+	columns := fmt.Sprintf("%[1]s.created_at, %[1]s.id", alias)
+
+	if cursor == nil {
+		return fmt.Sprintf(`
+		ORDER BY %[1]s.created_at DESC, %[1]s.id DESC
+		LIMIT $%[2]d`, alias, first), []any{limit + 1}
+	}
+
+	comparison, order := "<", "DESC"
+	if cursor.Direction == Backward {
+		comparison, order = ">", "ASC"
+	}
+
+	return fmt.Sprintf(`
+		AND (%[1]s) %[2]s ($%[4]d, $%[5]d)
+		ORDER BY %[6]s.created_at %[3]s, %[6]s.id %[3]s
+		LIMIT $%[7]d`, columns, comparison, order, first, first+1, alias, first+2),
+		[]any{cursor.CreatedAt, cursor.Id, limit + 1}
+}
@@ -0,0 +1,81 @@
+package safehttp
+
+import (
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":      true,
+		"2606:4700::1111":    true,
+		"127.0.0.1":          false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"169.254.169.254":    false,
+		"100.64.0.1":         false,
+		"0.0.0.0":            false,
+		"::1":                false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"::ffff:127.0.0.1":   false,
+		"::ffff:169.254.1.1": false,
+		"224.0.0.1":          false,
+	}
+
+	for raw, want := range cases {
+		if got := IsPublic(netip.MustParseAddr(raw)); got != want {
+			t.Errorf("IsPublic(%s) = %t, want %t", raw, got, want)
+		}
+	}
+}
+
+func TestIsPublicHost(t *testing.T) {
+	cases := map[string]bool{
+		"example.com":     true,
+		"localhost":       false,
+		"LOCALHOST.":      false,
+		"api.localhost":   false,
+		"127.0.0.1":       false,
+		"[::1]":           false,
+		"169.254.169.254": false,
+		"93.184.216.34":   true,
+		"":                false,
+	}
+
+	for host, want := range cases {
+		if got := IsPublicHost(host); got != want {
+			t.Errorf("IsPublicHost(%q) = %t, want %t", host, got, want)
+		}
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := NewClient(time.Second).Get(srv.URL)
+	if !stderrors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("expected ErrForbiddenAddress, got %v", err)
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	for target, allowed := range map[string]bool{
+		"https://example.com/next":       true,
+		"http://127.0.0.1/admin":         false,
+		"http://[::1]/":                  false,
+		"http://169.254.169.254/latest/": false,
+		"ftp://example.com/file":         false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if err := checkRedirect(req, nil); (err == nil) != allowed {
+			t.Errorf("checkRedirect(%s) = %v, allowed %t", target, err, allowed)
+		}
+	}
+}